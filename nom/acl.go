@@ -0,0 +1,141 @@
+package nom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ACLAction is the action of an ACL rule.
+type ACLAction uint8
+
+// Valid values for ACLAction.
+const (
+	ACLDeny ACLAction = iota
+	ACLAllow
+)
+
+func (a ACLAction) String() string {
+	switch a {
+	case ACLDeny:
+		return "deny"
+	case ACLAllow:
+		return "allow"
+	}
+	return "unknown"
+}
+
+// ACLRule applies Action to the addresses in Prefix.
+type ACLRule struct {
+	Prefix MaskedIPv4Addr
+	Action ACLAction
+}
+
+func (r ACLRule) String() string {
+	return fmt.Sprintf("%v %v", r.Action, r.Prefix.Canonicalize())
+}
+
+// CompileIPv4ACL compiles an ordered list of rules, in which the first rule
+// that matches an address wins (as in most switch and router ACLs), into a
+// trie that maps each prefix to its ACLAction. Looking up an address with
+// IPv4Trie.LongestMatch then returns the action of the first matching rule,
+// in time independent of the number of rules.
+//
+// A rule is shadowed if an earlier rule subsumes its prefix, since it can
+// never match; eg, "allow 10.1.0.0/16" after "deny 10.0.0.0/8". Shadowed
+// rules, as well as rules with non-contiguous masks, are left out of the
+// trie and are all reported in the returned error. The trie is returned even
+// when there is an error, so that callers can choose to proceed with it.
+func CompileIPv4ACL(rules []ACLRule) (*IPv4Trie, error) {
+	var problems []string
+	t := NewIPv4Trie()
+	for i, r := range rules {
+		if !IsValidNetmask4(r.Prefix.Mask) {
+			problems = append(problems, fmt.Sprintf("#%d (%v/%v) has a "+
+				"non-contiguous mask", i, r.Prefix.Addr, r.Prefix.Mask))
+			continue
+		}
+		if j := shadowingRule(rules[:i], r); j >= 0 {
+			problems = append(problems, fmt.Sprintf("#%d (%v) is shadowed by #%d "+
+				"(%v)", i, r, j, rules[j]))
+			continue
+		}
+		t.Insert(r.Prefix, r.Action)
+	}
+
+	if len(problems) != 0 {
+		return t, fmt.Errorf("invalid ACL rules: %s", strings.Join(problems, ", "))
+	}
+	return t, nil
+}
+
+// shadowingRule returns the index of the first valid rule in earlier that
+// subsumes r, or -1 if there is none.
+func shadowingRule(earlier []ACLRule, r ACLRule) int {
+	for j, e := range earlier {
+		if IsValidNetmask4(e.Prefix.Mask) && e.Prefix.Subsumes(r.Prefix) {
+			return j
+		}
+	}
+	return -1
+}
+
+// EvalIPv4Policy evaluates the ordered list of rules for ip, and returns
+// whether the first rule that matches ip allows it, along with the index of
+// that rule. Unlike CompileIPv4ACL, rules can have non-contiguous masks. When
+// no rule matches, ip is denied (as with the implicit deny at the end of
+// switch and router ACLs) and matched is -1.
+//
+// Evaluation is linear in the number of rules; compile the rules with
+// CompileIPv4ACL to evaluate long lists on every packet.
+func EvalIPv4Policy(ip IPv4Addr, rules []ACLRule) (allow bool, matched int) {
+	for i, r := range rules {
+		if r.Prefix.Match(ip) {
+			return r.Action == ACLAllow, i
+		}
+	}
+	return false, -1
+}
+
+// IPv6ACLRule applies Action to the addresses in Prefix.
+type IPv6ACLRule struct {
+	Prefix MaskedIPv6Addr
+	Action ACLAction
+}
+
+func (r IPv6ACLRule) String() string {
+	return fmt.Sprintf("%v %v", r.Action, r.Prefix.Canonicalize())
+}
+
+// EvalIPv6Policy is like EvalIPv4Policy but for IPv6 addresses.
+func EvalIPv6Policy(ip IPv6Addr, rules []IPv6ACLRule) (allow bool,
+	matched int) {
+
+	for i, r := range rules {
+		if r.Prefix.Match(ip) {
+			return r.Action == ACLAllow, i
+		}
+	}
+	return false, -1
+}
+
+// MACACLRule applies Action to the MAC addresses matched by Addr.
+type MACACLRule struct {
+	Addr   MaskedMACAddr
+	Action ACLAction
+}
+
+func (r MACACLRule) String() string {
+	return fmt.Sprintf("%v %v", r.Action, r.Addr.Canonicalize())
+}
+
+// EvalMACPolicy is like EvalIPv4Policy but for MAC addresses.
+func EvalMACPolicy(mac MACAddr, rules []MACACLRule) (allow bool,
+	matched int) {
+
+	for i, r := range rules {
+		if r.Addr.Match(mac) {
+			return r.Action == ACLAllow, i
+		}
+	}
+	return false, -1
+}
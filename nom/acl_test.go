@@ -0,0 +1,150 @@
+package nom
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustACLRule(t *testing.T, prefix string, action ACLAction) ACLRule {
+	p, err := ParseCIDRv4(prefix)
+	if err != nil {
+		t.Fatalf("cannot parse %v: %v", prefix, err)
+	}
+	return ACLRule{Prefix: p, Action: action}
+}
+
+func TestCompileIPv4ACL(t *testing.T) {
+	rules := []ACLRule{
+		mustACLRule(t, "10.1.2.0/24", ACLAllow),
+		mustACLRule(t, "10.0.0.0/8", ACLDeny),
+		mustACLRule(t, "0.0.0.0/0", ACLAllow),
+	}
+	trie, err := CompileIPv4ACL(rules)
+	if err != nil {
+		t.Fatalf("cannot compile the ACL: %v", err)
+	}
+
+	tests := map[IPv4Addr]ACLAction{
+		{10, 1, 2, 3}:  ACLAllow,
+		{10, 1, 3, 3}:  ACLDeny,
+		{192, 0, 2, 1}: ACLAllow,
+	}
+	for ip, want := range tests {
+		v, _, ok := trie.LongestMatch(ip)
+		if !ok || v != want {
+			t.Errorf("invalid action for %v: actual=%v want=%v", ip, v, want)
+		}
+	}
+}
+
+func TestCompileIPv4ACLShadowing(t *testing.T) {
+	rules := []ACLRule{
+		mustACLRule(t, "10.0.0.0/8", ACLDeny),
+		mustACLRule(t, "10.1.0.0/16", ACLAllow),
+		mustACLRule(t, "192.0.2.0/24", ACLAllow),
+		mustACLRule(t, "192.0.2.0/24", ACLDeny),
+	}
+	trie, err := CompileIPv4ACL(rules)
+	if err == nil {
+		t.Fatalf("no error for shadowed rules")
+	}
+	for _, want := range []string{
+		"#1 (allow 10.1.0.0/16) is shadowed by #0 (deny 10.0.0.0/8)",
+		"#3 (deny 192.0.2.0/24) is shadowed by #2 (allow 192.0.2.0/24)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q is not reported in %q", want, err)
+		}
+	}
+
+	if trie.Len() != 2 {
+		t.Errorf("invalid number of compiled rules: actual=%v want=2", trie.Len())
+	}
+	if v, _, _ := trie.LongestMatch(IPv4Addr{10, 1, 0, 1}); v != ACLDeny {
+		t.Errorf("shadowed rule is compiled: actual=%v want=%v", v, ACLDeny)
+	}
+	if v, _, _ := trie.LongestMatch(IPv4Addr{192, 0, 2, 1}); v != ACLAllow {
+		t.Errorf("shadowed rule is compiled: actual=%v want=%v", v, ACLAllow)
+	}
+}
+
+func TestEvalIPv4Policy(t *testing.T) {
+	rules := []ACLRule{
+		mustACLRule(t, "10.1.2.0/24", ACLAllow),
+		mustACLRule(t, "10.0.0.0/8", ACLDeny),
+		mustACLRule(t, "10.1.0.0/16", ACLAllow),
+		mustACLRule(t, "192.0.2.0/24", ACLAllow),
+	}
+	tests := []struct {
+		ip      IPv4Addr
+		allow   bool
+		matched int
+	}{
+		{IPv4Addr{10, 1, 2, 3}, true, 0},
+		// The deny rule matches first, even though a later rule allows it.
+		{IPv4Addr{10, 1, 3, 3}, false, 1},
+		{IPv4Addr{192, 0, 2, 1}, true, 3},
+		// Denied by default.
+		{IPv4Addr{198, 51, 100, 1}, false, -1},
+	}
+	for _, tc := range tests {
+		allow, matched := EvalIPv4Policy(tc.ip, rules)
+		if allow != tc.allow || matched != tc.matched {
+			t.Errorf("invalid evaluation of %v: actual=%v,%v want=%v,%v", tc.ip,
+				allow, matched, tc.allow, tc.matched)
+		}
+	}
+	if allow, matched := EvalIPv4Policy(IPv4Addr{10, 0, 0, 1}, nil); allow ||
+		matched != -1 {
+		t.Errorf("invalid evaluation of an empty policy: actual=%v,%v", allow,
+			matched)
+	}
+}
+
+func TestEvalIPv6Policy(t *testing.T) {
+	deny, _ := ParseCIDRv6("2001:db8:1::/48")
+	allow, _ := ParseCIDRv6("2001:db8::/32")
+	rules := []IPv6ACLRule{
+		{Prefix: deny, Action: ACLDeny},
+		{Prefix: allow, Action: ACLAllow},
+	}
+	tests := map[string]int{
+		"2001:db8:1::1": 0,
+		"2001:db8:2::1": 1,
+		"fe80::1":       -1,
+	}
+	for s, want := range tests {
+		ip, _ := ParseIPv6(s)
+		a, matched := EvalIPv6Policy(ip, rules)
+		if matched != want || a != (want == 1) {
+			t.Errorf("invalid evaluation of %v: actual=%v,%v want=%v,%v", s, a,
+				matched, want == 1, want)
+		}
+	}
+}
+
+func TestEvalMACPolicy(t *testing.T) {
+	host := MACAddr{0x00, 0x1b, 0x21, 0x0a, 0x0b, 0x0c}
+	rules := []MACACLRule{
+		{Addr: MaskedMACAddr{Addr: host, Mask: BroadcastMAC}, Action: ACLDeny},
+		{
+			Addr: MaskedMACAddr{
+				Addr: MACAddr{0x00, 0x1b, 0x21},
+				Mask: MACAddr{0xff, 0xff, 0xff},
+			},
+			Action: ACLAllow,
+		},
+	}
+	tests := map[MACAddr]int{
+		host:                                 0,
+		{0x00, 0x1b, 0x21, 0x01, 0x02, 0x03}: 1,
+		{0x00, 0x1c, 0x21, 0x01, 0x02, 0x03}: -1,
+	}
+	for mac, want := range tests {
+		a, matched := EvalMACPolicy(mac, rules)
+		if matched != want || a != (want == 1) {
+			t.Errorf("invalid evaluation of %v: actual=%v,%v want=%v,%v", mac, a,
+				matched, want == 1, want)
+		}
+	}
+}
@@ -2,12 +2,74 @@ package nom
 
 import (
 	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+)
+
+// Addr is the common interface of the address types in NOM: MACAddr,
+// IPv4Addr, and IPv6Addr.
+type Addr interface {
+	// Family returns the address family.
+	Family() AddrFamily
+	// Key returns the raw bytes of the address as a string.
+	Key() string
+	String() string
+}
+
+// MaskedAddr is the common interface of the masked address types in NOM:
+// MaskedMACAddr, MaskedIPv4Addr, and MaskedIPv6Addr.
+type MaskedAddr interface {
+	// Family returns the family of the masked address.
+	Family() AddrFamily
+	// Key returns the masked address and its mask as a string.
+	Key() string
+	String() string
+	// MatchErr returns whether the masked address matches a, or
+	// ErrFamilyMismatch if a is not of the same family.
+	MatchErr(a Addr) (bool, error)
+}
+
+// ErrFamilyMismatch is returned when an address is used along with an
+// address or a prefix of another family.
+var ErrFamilyMismatch = errors.New("nom: address family mismatch")
+
+// AddrFamily is the family of an address.
+type AddrFamily uint8
+
+// Valid values for AddrFamily.
+const (
+	AddrFamilyMAC AddrFamily = iota + 1
+	AddrFamilyIPv4
+	AddrFamilyIPv6
+)
+
+func (f AddrFamily) String() string {
+	switch f {
+	case AddrFamilyMAC:
+		return "mac"
+	case AddrFamilyIPv4:
+		return "ipv4"
+	case AddrFamilyIPv6:
+		return "ipv6"
+	}
+	return fmt.Sprintf("family(%d)", uint8(f))
+}
+
+// Length of addresses in bytes.
+const (
+	MACLen  = 6
+	IPv4Len = 4
+	IPv6Len = 16
 )
 
 // MACAddr represents a MAC address.
-type MACAddr [6]byte
+type MACAddr [MACLen]byte
 
 var (
 	MaskNoneMAC            MACAddr   = [6]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
@@ -27,9 +89,40 @@ var (
 		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
 )
 
+// The smallest and the largest addresses of each family, for bounds checks.
+// Next and Add wrap around from the largest address to the smallest one.
+var (
+	MinMAC  = MACAddr{}
+	MaxMAC  = MACAddr{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	MinIPv4 = IPv4Addr{}
+	MaxIPv4 = IPv4Addr{0xFF, 0xFF, 0xFF, 0xFF}
+	MinIPv6 = IPv6Addr{}
+	MaxIPv6 = IPv6Addr{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+// Family returns AddrFamilyMAC.
+func (m MACAddr) Family() AddrFamily {
+	return AddrFamilyMAC
+}
+
 func (m MACAddr) String() string {
-	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x",
-		m[0], m[1], m[2], m[3], m[4], m[5])
+	var buf [17]byte
+	return string(m.AppendTo(buf[:0]))
+}
+
+// AppendTo appends the textual form of m, as returned by String, to b and
+// returns the extended buffer. Unlike String, it does not allocate when b has
+// enough capacity, which makes it suitable for high-rate logging.
+func (m MACAddr) AppendTo(b []byte) []byte {
+	const hex = "0123456789abcdef"
+	for i, v := range m {
+		if i != 0 {
+			b = append(b, ':')
+		}
+		b = append(b, hex[v>>4], hex[v&0xF])
+	}
+	return b
 }
 
 // Key returns an string represtation of the MAC address suitable to store in
@@ -38,6 +131,12 @@ func (m MACAddr) Key() string {
 	return string(m[:])
 }
 
+// Hash returns a 32-bit FNV-1a hash of the MAC address. The hash is stable
+// across processes and releases, but is not cryptographically secure.
+func (m MACAddr) Hash() uint32 {
+	return hash32(m[:])
+}
+
 // IsBroadcast returns whether the MAC address is a broadcast address.
 func (m MACAddr) IsBroadcast() bool {
 	return m == BroadcastMAC
@@ -51,14 +150,77 @@ func (m MACAddr) IsMulticast() bool {
 		m.hasPrefix(IPv6MulticastPrefix, 2)
 }
 
-// IsLLDP returns whether the mac address is a multicast address used for LLDP.
+// IsGroup returns whether the individual/group (I/G) bit of the MAC address
+// is set, ie, whether it is a group address. Unlike IsMulticast, which only
+// recognizes well-known multicast ranges, it checks the bit of any address.
+func (m MACAddr) IsGroup() bool {
+	return m[0]&0x01 != 0
+}
+
+// IsLocallyAdministered returns whether the universal/local (U/L) bit of the
+// MAC address is set, ie, whether it is not assigned by a vendor.
+func (m MACAddr) IsLocallyAdministered() bool {
+	return m[0]&0x02 != 0
+}
+
+// SetMulticast sets or clears the individual/group (I/G) bit of the MAC
+// address, which is the least significant bit of its first octet.
+func (m *MACAddr) SetMulticast(multicast bool) {
+	if multicast {
+		m[0] |= 0x01
+	} else {
+		m[0] &^= 0x01
+	}
+}
+
+// SetLocallyAdministered sets or clears the universal/local (U/L) bit of the
+// MAC address, which is the second least significant bit of its first octet.
+// Synthesized addresses should be locally administered, so that they never
+// collide with vendor-assigned addresses.
+func (m *MACAddr) SetLocallyAdministered(local bool) {
+	if local {
+		m[0] |= 0x02
+	} else {
+		m[0] &^= 0x02
+	}
+}
+
+// IsLLDP returns whether the mac address is a multicast address used for LLDP,
+// either one of LLDPMulticastMACs or an address registered by
+// RegisterLLDPMulticast.
 func (m MACAddr) IsLLDP() bool {
 	for _, lm := range LLDPMulticastMACs {
 		if m == lm {
 			return true
 		}
 	}
-	return false
+
+	customLLDP.RLock()
+	defer customLLDP.RUnlock()
+	_, ok := customLLDP.macs[m]
+	return ok
+}
+
+var customLLDP = struct {
+	sync.RWMutex
+	macs map[MACAddr]struct{}
+}{macs: make(map[MACAddr]struct{})}
+
+// RegisterLLDPMulticast adds mac to the destination addresses recognized as
+// LLDP by IsLLDP, for deployments whose devices send LLDP to nonstandard
+// group addresses. It is meant to be called during initialization.
+func RegisterLLDPMulticast(mac MACAddr) {
+	customLLDP.Lock()
+	defer customLLDP.Unlock()
+	customLLDP.macs[mac] = struct{}{}
+}
+
+// EqualConstantTime returns whether m and thatm are equal, in a time that does
+// not depend on their contents. Plain == is fine for most uses; this is only
+// needed for security-sensitive membership checks, such as MAC allowlists for
+// port security, where timing side channels matter.
+func (m MACAddr) EqualConstantTime(thatm MACAddr) bool {
+	return subtle.ConstantTimeCompare(m[:], thatm[:]) == 1
 }
 
 func (m MACAddr) hasPrefix(p MACAddr, l int) bool {
@@ -78,6 +240,61 @@ func (m MACAddr) Mask(mask MACAddr) MACAddr {
 	return masked
 }
 
+// Uint64 returns the MAC address as a 48-bit big-endian integer. For example,
+// it returns 0x0123456789AB for 01:23:45:67:89:ab.
+func (m MACAddr) Uint64() uint64 {
+	return uint64(m[0])<<40 | uint64(m[1])<<32 | uint64(m[2])<<24 |
+		uint64(m[3])<<16 | uint64(m[4])<<8 | uint64(m[5])
+}
+
+// FromUint64 loads the MAC address from the lower 48 bits of addr.
+func (m *MACAddr) FromUint64(addr uint64) {
+	for i := 0; i < 6; i++ {
+		m[i] = byte((addr >> uint(8*(5-i))) & 0xFF)
+	}
+}
+
+// Next returns the MAC address that follows m when m is read as a 48-bit
+// big-endian integer. ff:ff:ff:ff:ff:ff wraps around to 00:00:00:00:00:00.
+func (m MACAddr) Next() MACAddr {
+	next := m
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// Add returns m + n when m is read as a 48-bit big-endian integer. n can be
+// negative, and the result wraps around modulo 2^48; eg, adding 1 to
+// ff:ff:ff:ff:ff:ff results in 00:00:00:00:00:00.
+func (m MACAddr) Add(n int64) MACAddr {
+	var sum MACAddr
+	sum.FromUint64((m.Uint64() + uint64(n)) & (1<<48 - 1))
+	return sum
+}
+
+// Truncate returns m with all bits beyond the first prefixLen bits cleared.
+// For example, Truncate(24) keeps the OUI of the address. prefixLen is
+// clamped to [0, 48]; use NetworkWith to reject invalid lengths instead.
+func (m MACAddr) Truncate(prefixLen int) MACAddr {
+	prefixLen = clampPrefixLen(prefixLen, 48)
+	var t MACAddr
+	t.FromUint64(m.Uint64() &^ (uint64(1)<<uint(48-prefixLen) - 1))
+	return t
+}
+
+// NetworkWith is like Truncate but returns an error instead of clamping
+// prefixLen if it is not in [0, 48].
+func (m MACAddr) NetworkWith(prefixLen int) (MACAddr, error) {
+	if prefixLen < 0 || prefixLen > 48 {
+		return MACAddr{}, fmt.Errorf("invalid MAC prefix length %d", prefixLen)
+	}
+	return m.Truncate(prefixLen), nil
+}
+
 func (m MACAddr) Less(thatm MACAddr) bool {
 	for i := range thatm {
 		switch {
@@ -101,6 +318,89 @@ func (mm MaskedMACAddr) Match(mac MACAddr) bool {
 	return mm.Mask.Mask(mm.Addr) == mm.Mask.Mask(mac)
 }
 
+// AnyMAC is the masked address that matches every MAC address.
+var AnyMAC = MaskedMACAddr{}
+
+// IsAny returns whether the masked address matches every MAC address; ie,
+// whether its mask is all zeros.
+func (mm MaskedMACAddr) IsAny() bool {
+	return mm.Mask == MACAddr{}
+}
+
+// PrefixLen returns the number of leading bits of the mask up to its last one
+// bit. For example, it returns 24 for a mask of ff:ff:ff:00:00:00.
+func (mm MaskedMACAddr) PrefixLen() int {
+	m := mm.Mask.Uint64()
+	l := 48
+	for ; l > 0 && m&1 == 0; l-- {
+		m >>= 1
+	}
+	return l
+}
+
+// IsExact returns whether the masked address is a /48, ie, whether it matches
+// only Addr.
+func (mm MaskedMACAddr) IsExact() bool {
+	return mm.Mask == MaskNoneMAC
+}
+
+// IsOctetAligned returns whether the mask is contiguous and its prefix length
+// is a multiple of 8, such as an OUI mask.
+func (mm MaskedMACAddr) IsOctetAligned() bool {
+	wildcard := ^mm.Mask.Uint64() & (1<<48 - 1)
+	return wildcard&(wildcard+1) == 0 && mm.PrefixLen()%8 == 0
+}
+
+// MatchErr is like Match but accepts any address. Unlike Match, which cannot
+// be misused, it returns ErrFamilyMismatch if a is not a MAC address. This
+// surfaces programming errors in generic code, instead of reporting them as
+// a no-match.
+func (mm MaskedMACAddr) MatchErr(a Addr) (bool, error) {
+	mac, ok := a.(MACAddr)
+	if !ok {
+		return false, ErrFamilyMismatch
+	}
+	return mm.Match(mac), nil
+}
+
+// Family returns AddrFamilyMAC.
+func (mm MaskedMACAddr) Family() AddrFamily {
+	return AddrFamilyMAC
+}
+
+func (mm MaskedMACAddr) String() string {
+	return fmt.Sprintf("%v/%v", mm.Addr, mm.Mask)
+}
+
+// Key returns a compact string representation of the masked MAC address
+// suitable to store in dictionaries. Bits outside of the mask are ignored, so
+// that equivalent masked addresses always have the same key.
+func (mm MaskedMACAddr) Key() string {
+	addr := mm.Addr.Mask(mm.Mask)
+	return maskedKey(addr[:], mm.Mask[:])
+}
+
+// Network returns the address with the bits outside of the mask cleared.
+func (mm MaskedMACAddr) Network() MACAddr {
+	return mm.Addr.Mask(mm.Mask)
+}
+
+// SameSubnet returns whether mm and thatmm match the same set of addresses,
+// regardless of the bits they have set outside of their masks.
+func (mm MaskedMACAddr) SameSubnet(thatmm MaskedMACAddr) bool {
+	return mm.Mask == thatmm.Mask && mm.Network() == thatmm.Network()
+}
+
+// IsCanonical returns whether mm has no bits set outside of its mask.
+func (mm MaskedMACAddr) IsCanonical() bool {
+	return mm.Addr == mm.Addr.Mask(mm.Mask)
+}
+
+// Canonicalize returns a copy of mm with the bits outside of the mask cleared.
+func (mm MaskedMACAddr) Canonicalize() MaskedMACAddr {
+	return MaskedMACAddr{Addr: mm.Addr.Mask(mm.Mask), Mask: mm.Mask}
+}
+
 // Subsumes returns whether this mask address includes all the addresses matched
 // by thatmm.
 func (mm MaskedMACAddr) Subsumes(thatmm MaskedMACAddr) bool {
@@ -110,17 +410,59 @@ func (mm MaskedMACAddr) Subsumes(thatmm MaskedMACAddr) bool {
 	return mm.Match(thatmm.Addr.Mask(thatmm.Mask))
 }
 
+// WithinSpace returns whether all the addresses matched by mm are inside
+// space. See MaskedIPv4Addr.WithinSpace.
+func (mm MaskedMACAddr) WithinSpace(space MaskedMACAddr) bool {
+	return space.Subsumes(mm)
+}
+
+// Less orders masked addresses by their network address and then by their
+// mask. See MaskedIPv4Addr.Less.
+func (mm MaskedMACAddr) Less(thatmm MaskedMACAddr) bool {
+	n, thatn := mm.Network(), thatmm.Network()
+	if n != thatn {
+		return n.Less(thatn)
+	}
+	if mm.Mask != thatmm.Mask {
+		return mm.Mask.Less(thatmm.Mask)
+	}
+	return mm.Addr.Less(thatmm.Addr)
+}
+
 // IPv4Addr represents an IP version 4 address in big endian byte order.
 // For example, 127.0.0.1 is represented as IPv4Addr{127, 0, 0, 1}.
-type IPv4Addr [4]byte
+type IPv4Addr [IPv4Len]byte
 
-// Uint converts the IP version 4 address into a 32-bit integer in little
-// endian byte order.
-func (ip IPv4Addr) Uint() uint32 {
+// Uint32 converts the IP version 4 address into a 32-bit integer whose most
+// significant byte is the first octet. For example, it returns 0x7F000001 for
+// 127.0.0.1.
+func (ip IPv4Addr) Uint32() uint32 {
 	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 |
 		uint32(ip[3])
 }
 
+// Family returns AddrFamilyIPv4.
+func (ip IPv4Addr) Family() AddrFamily {
+	return AddrFamilyIPv4
+}
+
+// Key returns an string represtation of the IP address suitable to store in
+// dictionaries. It is more efficient compared to IPv4Addr.String().
+func (ip IPv4Addr) Key() string {
+	return string(ip[:])
+}
+
+// Hash returns a 32-bit FNV-1a hash of the IP address. The hash is stable
+// across processes and releases, but is not cryptographically secure.
+func (ip IPv4Addr) Hash() uint32 {
+	return hash32(ip[:])
+}
+
+// Uint is equivalent to Uint32.
+func (ip IPv4Addr) Uint() uint32 {
+	return ip.Uint32()
+}
+
 // FromUint loads the ip address from addr.
 func (ip *IPv4Addr) FromUint(addr uint32) {
 	for i := 0; i < 4; i++ {
@@ -128,10 +470,27 @@ func (ip *IPv4Addr) FromUint(addr uint32) {
 	}
 }
 
+// Next returns the IP address that follows ip. 255.255.255.255 wraps around to
+// 0.0.0.0.
+func (ip IPv4Addr) Next() IPv4Addr {
+	var next IPv4Addr
+	next.FromUint(ip.Uint32() + 1)
+	return next
+}
+
+// Add returns ip + n when ip is read as a 32-bit big-endian integer. n can be
+// negative, and the result wraps around modulo 2^32; eg, adding -1 to 0.0.0.0
+// results in 255.255.255.255.
+func (ip IPv4Addr) Add(n int64) IPv4Addr {
+	var sum IPv4Addr
+	sum.FromUint(ip.Uint32() + uint32(n))
+	return sum
+}
+
 // PopCount returns the number of ones in the IP address. For example, it
 // returns 16 for IPv4Addr{255, 255, 0, 0}.
 func (ip IPv4Addr) PopCount() uint32 {
-	v := ip.Uint()
+	v := ip.Uint32()
 	v -= (v >> 1) & 0x55555555
 	v = ((v >> 2) & 0x33333333) + v&0x33333333
 	v = ((v >> 4) + v) & 0x0F0F0F0F
@@ -148,6 +507,46 @@ func (ip IPv4Addr) Mask(mask IPv4Addr) IPv4Addr {
 	return masked
 }
 
+// EqualConstantTime returns whether ip and thatip are equal, in a time that
+// does not depend on their contents. See MACAddr.EqualConstantTime.
+func (ip IPv4Addr) EqualConstantTime(thatip IPv4Addr) bool {
+	return subtle.ConstantTimeCompare(ip[:], thatip[:]) == 1
+}
+
+// Truncate returns ip with all bits beyond the first prefixLen bits cleared.
+// For example, Truncate(24) returns 10.1.2.0 for 10.1.2.3. It does not
+// allocate, so it is suitable to build aggregation keys in hot paths.
+// prefixLen is clamped to [0, 32]: negative lengths are treated as 0 and
+// lengths above 32 as 32. Use NetworkWith to reject invalid lengths instead.
+func (ip IPv4Addr) Truncate(prefixLen int) IPv4Addr {
+	prefixLen = clampPrefixLen(prefixLen, 32)
+	var t IPv4Addr
+	t.FromUint(ip.Uint32() & (^uint32(0) << uint(32-prefixLen)))
+	return t
+}
+
+// NetworkWith returns the network address of ip in a prefix of length
+// prefixLen; eg, 10.1.2.0 for 10.1.2.3 and 24. Unlike Truncate, it returns an
+// error if prefixLen is not in [0, 32], which makes it suitable for prefix
+// lengths that come from configuration or the network.
+func (ip IPv4Addr) NetworkWith(prefixLen int) (IPv4Addr, error) {
+	if prefixLen < 0 || prefixLen > 32 {
+		return IPv4Addr{}, fmt.Errorf("invalid IPv4 prefix length %d", prefixLen)
+	}
+	return ip.Truncate(prefixLen), nil
+}
+
+// clampPrefixLen returns prefixLen limited to [0, max].
+func clampPrefixLen(prefixLen, max int) int {
+	switch {
+	case prefixLen < 0:
+		return 0
+	case prefixLen > max:
+		return max
+	}
+	return prefixLen
+}
+
 // Less returns whether ip is less than thatip.
 func (ip IPv4Addr) Less(thatip IPv4Addr) bool {
 	for i := range ip {
@@ -177,7 +576,20 @@ func (ip IPv4Addr) AsCIDRMask() int {
 }
 
 func (ip IPv4Addr) String() string {
-	return fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], ip[3])
+	var buf [15]byte
+	return string(ip.AppendTo(buf[:0]))
+}
+
+// AppendTo appends the textual form of ip, as returned by String, to b and
+// returns the extended buffer. See MACAddr.AppendTo.
+func (ip IPv4Addr) AppendTo(b []byte) []byte {
+	for i, v := range ip {
+		if i != 0 {
+			b = append(b, '.')
+		}
+		b = strconv.AppendUint(b, uint64(v), 10)
+	}
+	return b
 }
 
 // CIDRToMaskedIPv4 converts a CIDR-style IP address into a NOM masked IP
@@ -190,15 +602,126 @@ func CIDRToMaskedIPv4(addr uint32, mask uint) MaskedIPv4Addr {
 	return maskedip
 }
 
+// MaskFromPrefixLen4 returns the IPv4 mask of a prefix of length l. For
+// example, it returns 255.255.255.0 for 24.
+func MaskFromPrefixLen4(l int) IPv4Addr {
+	var mask IPv4Addr
+	copy(mask[:], addrBits(MaskNoneIPV4[:]).Masked(l))
+	return mask
+}
+
+// IsValidNetmask4 returns whether mask is a contiguous IPv4 netmask; ie, some
+// ones followed by zeros.
+func IsValidNetmask4(mask IPv4Addr) bool {
+	return mask == MaskFromPrefixLen4(mask.AsCIDRMask())
+}
+
 // MaskedIPv4Addr represents a masked IP address (ie, an IPv4 prefix)
 type MaskedIPv4Addr struct {
 	Addr IPv4Addr
 	Mask IPv4Addr
 }
 
+// DefaultRouteIPv4 is the IPv4 default route, 0.0.0.0/0.
+var DefaultRouteIPv4 = MaskedIPv4Addr{}
+
+// IsDefaultRoute returns whether the prefix is a default route; ie, whether
+// its mask is all zeros.
+func (mi MaskedIPv4Addr) IsDefaultRoute() bool {
+	return mi.Mask == IPv4Addr{}
+}
+
+// AnyIPv4 is the masked address that matches every IPv4 address. It is equal
+// to DefaultRouteIPv4, but is meant for policies (eg, ACL rules) rather than
+// routes.
+var AnyIPv4 = MaskedIPv4Addr{}
+
+// IsAny returns whether the masked address matches every IPv4 address; ie,
+// whether its mask is all zeros. Classifiers can use it to skip matching
+// altogether. It is the policy counterpart of IsDefaultRoute.
+func (mi MaskedIPv4Addr) IsAny() bool {
+	return mi.Mask == IPv4Addr{}
+}
+
 // Match returns whether the masked IP address matches ip.
 func (mi MaskedIPv4Addr) Match(ip IPv4Addr) bool {
-	return mi.Addr.Mask(mi.Mask) == ip.Mask(mi.Mask)
+	return mi.MatchUint32(ip.Uint32())
+}
+
+// MatchErr is like Match but accepts any address, and returns
+// ErrFamilyMismatch if a is not an IPv4 address. See MaskedMACAddr.MatchErr.
+func (mi MaskedIPv4Addr) MatchErr(a Addr) (bool, error) {
+	ip, ok := a.(IPv4Addr)
+	if !ok {
+		return false, ErrFamilyMismatch
+	}
+	return mi.Match(ip), nil
+}
+
+// Family returns AddrFamilyIPv4.
+func (mi MaskedIPv4Addr) Family() AddrFamily {
+	return AddrFamilyIPv4
+}
+
+// MatchUint32 is like Match but accepts the IP address in the integer form
+// returned by IPv4Addr.Uint32. The masked address and the mask are converted
+// to integers on every call; to match many addresses against the same masked
+// address, use PrepareIPv4Match to precompute them once.
+func (mi MaskedIPv4Addr) MatchUint32(ip uint32) bool {
+	mask := mi.Mask.Uint32()
+	return mi.Addr.Uint32()&mask == ip&mask
+}
+
+// PrefixLen returns the length of the prefix. For example, it returns 24 for
+// 10.0.0.0/24.
+func (mi MaskedIPv4Addr) PrefixLen() int {
+	return mi.Mask.AsCIDRMask()
+}
+
+// IsHostRoute returns whether the prefix is a /32, ie, whether it matches only
+// Addr. Note that PrefixLen alone also returns 32 for non-contiguous masks
+// whose last bit is set, which are not host routes.
+func (mi MaskedIPv4Addr) IsHostRoute() bool {
+	return mi.PrefixLen() == 32 && IsValidNetmask4(mi.Mask)
+}
+
+// IsOctetAligned returns whether the mask is contiguous and its prefix length
+// is a multiple of 8; ie, whether the prefix can be written as a glob such as
+// 10.1.*.*. See GlobString.
+func (mi MaskedIPv4Addr) IsOctetAligned() bool {
+	return mi.PrefixLen()%8 == 0 && IsValidNetmask4(mi.Mask)
+}
+
+// Key returns a compact string representation of the prefix suitable to store
+// in dictionaries. Host bits are ignored, so that equivalent prefixes always
+// have the same key.
+func (mi MaskedIPv4Addr) Key() string {
+	addr := mi.Addr.Mask(mi.Mask)
+	return maskedKey(addr[:], mi.Mask[:])
+}
+
+// Network returns the network address of the prefix; ie, Addr with its host
+// bits cleared.
+func (mi MaskedIPv4Addr) Network() IPv4Addr {
+	return mi.Addr.Mask(mi.Mask)
+}
+
+// SameSubnet returns whether mi and thatmi represent the same subnet,
+// regardless of their host bits. Note that == is not reliable when Addr is
+// not canonical.
+func (mi MaskedIPv4Addr) SameSubnet(thatmi MaskedIPv4Addr) bool {
+	return mi.Mask == thatmi.Mask && mi.Network() == thatmi.Network()
+}
+
+// IsCanonical returns whether the prefix has no host bits set; ie, whether
+// Addr is aligned on the prefix boundary.
+func (mi MaskedIPv4Addr) IsCanonical() bool {
+	return mi.Addr == mi.Addr.Mask(mi.Mask)
+}
+
+// Canonicalize returns a copy of the prefix with its host bits cleared.
+func (mi MaskedIPv4Addr) Canonicalize() MaskedIPv4Addr {
+	return MaskedIPv4Addr{Addr: mi.Addr.Mask(mi.Mask), Mask: mi.Mask}
 }
 
 func (mi MaskedIPv4Addr) Subsumes(thatmi MaskedIPv4Addr) bool {
@@ -208,22 +731,166 @@ func (mi MaskedIPv4Addr) Subsumes(thatmi MaskedIPv4Addr) bool {
 	return mi.Addr.Mask(mi.Mask) == thatmi.Addr.Mask(mi.Mask)
 }
 
+// Overlaps returns whether mi and thatmi have any address in common, ie,
+// whether their addresses agree on every bit that both masks care about. For
+// prefixes with contiguous masks, this is the case iff one subsumes the
+// other, but masks that are not contiguous can overlap partially.
+func (mi MaskedIPv4Addr) Overlaps(thatmi MaskedIPv4Addr) bool {
+	return (mi.Addr.Uint32()^thatmi.Addr.Uint32())&mi.Mask.Uint32()&
+		thatmi.Mask.Uint32() == 0
+}
+
+// WithinSpace returns whether the prefix is entirely inside space; ie, whether
+// space subsumes it. This is useful to check that an allocation stays inside
+// the address space of its tenant.
+func (mi MaskedIPv4Addr) WithinSpace(space MaskedIPv4Addr) bool {
+	return space.Subsumes(mi)
+}
+
+// Less orders masked addresses by their network address (ie, ignoring host
+// bits) in ascending order, and then by their mask in ascending order. For
+// contiguous masks, this places a prefix right before the more specific
+// prefixes that share its network address; eg, 10.0.0.0/8 < 10.0.0.0/16 <
+// 10.0.0.1/32. Masked addresses that only differ in host bits are ordered by
+// Addr, so that the order is total.
+func (mi MaskedIPv4Addr) Less(thatmi MaskedIPv4Addr) bool {
+	n, thatn := mi.Network(), thatmi.Network()
+	if n != thatn {
+		return n.Less(thatn)
+	}
+	if mi.Mask != thatmi.Mask {
+		return mi.Mask.Less(thatmi.Mask)
+	}
+	return mi.Addr.Less(thatmi.Addr)
+}
+
 func (mi MaskedIPv4Addr) String() string {
 	return fmt.Sprintf("%v/%d", mi.Addr, mi.Mask.AsCIDRMask())
 }
 
+// GlobString returns the prefix as a glob-style pattern, where each wildcard
+// octet is replaced by "*" (eg, "10.0.*.*" for 10.0.0.0/16). Host bits are
+// ignored. Since globs can only express octet-aligned prefixes, it falls back
+// to String for prefixes whose length is not a multiple of 8 and for
+// non-contiguous masks.
+func (mi MaskedIPv4Addr) GlobString() string {
+	if !mi.IsOctetAligned() {
+		return mi.String()
+	}
+
+	l := mi.PrefixLen()
+	n := mi.Network()
+	var buf bytes.Buffer
+	for i := 0; i < IPv4Len; i++ {
+		if i != 0 {
+			buf.WriteByte('.')
+		}
+		if i < l/8 {
+			fmt.Fprintf(&buf, "%d", n[i])
+		} else {
+			buf.WriteByte('*')
+		}
+	}
+	return buf.String()
+}
+
 // IPv6Addr represents an IP version 6 address in big-endian byte order.
-type IPv6Addr [16]byte
+type IPv6Addr [IPv6Len]byte
+
+// Family returns AddrFamilyIPv6.
+func (ip IPv6Addr) Family() AddrFamily {
+	return AddrFamilyIPv6
+}
+
+// Key returns an string represtation of the IP address suitable to store in
+// dictionaries. It is more efficient compared to IPv6Addr.String().
+func (ip IPv6Addr) Key() string {
+	return string(ip[:])
+}
+
+// Hash returns a 32-bit FNV-1a hash of the IP address. The hash is stable
+// across processes and releases, but is not cryptographically secure.
+func (ip IPv6Addr) Hash() uint32 {
+	return hash32(ip[:])
+}
+
+// Uint64s returns the higher and the lower 64 bits of the IP address as
+// big-endian integers.
+func (ip IPv6Addr) Uint64s() (hi, lo uint64) {
+	return binary.BigEndian.Uint64(ip[:8]), binary.BigEndian.Uint64(ip[8:])
+}
+
+// FromUint64s loads the ip address from its higher and lower 64 bits.
+func (ip *IPv6Addr) FromUint64s(hi, lo uint64) {
+	binary.BigEndian.PutUint64(ip[:8], hi)
+	binary.BigEndian.PutUint64(ip[8:], lo)
+}
 
 // Mask masked the IP address with mask.
 func (ip IPv6Addr) Mask(mask IPv6Addr) IPv6Addr {
-	masked := ip
-	for i := range masked {
-		masked[i] &= mask[i]
-	}
+	hi, lo := ip.Uint64s()
+	mhi, mlo := mask.Uint64s()
+	var masked IPv6Addr
+	masked.FromUint64s(hi&mhi, lo&mlo)
 	return masked
 }
 
+// Next returns the IP address that follows ip. MaxIPv6 wraps around to
+// MinIPv6.
+func (ip IPv6Addr) Next() IPv6Addr {
+	hi, lo := ip.Uint64s()
+	var next IPv6Addr
+	next.FromUint64s(addUint128(hi, lo, 0))
+	return next
+}
+
+// ipv6Modulus is 2^128.
+var ipv6Modulus = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// Add returns ip + n when ip is read as a 128-bit big-endian integer. n can be
+// negative, and the result wraps around modulo 2^128.
+func (ip IPv6Addr) Add(n *big.Int) IPv6Addr {
+	x := new(big.Int).SetBytes(ip[:])
+	x.Add(x, n)
+	x.Mod(x, ipv6Modulus)
+	var sum IPv6Addr
+	b := x.Bytes()
+	copy(sum[IPv6Len-len(b):], b)
+	return sum
+}
+
+// EqualConstantTime returns whether ip and thatip are equal, in a time that
+// does not depend on their contents. See MACAddr.EqualConstantTime.
+func (ip IPv6Addr) EqualConstantTime(thatip IPv6Addr) bool {
+	return subtle.ConstantTimeCompare(ip[:], thatip[:]) == 1
+}
+
+// Truncate returns ip with all bits beyond the first prefixLen bits cleared.
+// Similar to IPv4Addr.Truncate, it does not allocate and it clamps prefixLen
+// to [0, 128].
+func (ip IPv6Addr) Truncate(prefixLen int) IPv6Addr {
+	prefixLen = clampPrefixLen(prefixLen, 128)
+	hi, lo := ip.Uint64s()
+	if prefixLen <= 64 {
+		hi &^= uint64(1)<<uint(64-prefixLen) - 1
+		lo = 0
+	} else {
+		lo &^= uint64(1)<<uint(128-prefixLen) - 1
+	}
+	var t IPv6Addr
+	t.FromUint64s(hi, lo)
+	return t
+}
+
+// NetworkWith is like Truncate but returns an error instead of clamping
+// prefixLen if it is not in [0, 128]. See IPv4Addr.NetworkWith.
+func (ip IPv6Addr) NetworkWith(prefixLen int) (IPv6Addr, error) {
+	if prefixLen < 0 || prefixLen > 128 {
+		return IPv6Addr{}, fmt.Errorf("invalid IPv6 prefix length %d", prefixLen)
+	}
+	return ip.Truncate(prefixLen), nil
+}
+
 // Less returns whether ip is less than thatip.
 func (ip IPv6Addr) Less(thatip IPv6Addr) bool {
 	for i := range ip {
@@ -237,31 +904,99 @@ func (ip IPv6Addr) Less(thatip IPv6Addr) bool {
 	return false
 }
 
+// String returns the canonical text form of ip as described in RFC 5952: the
+// longest run of two or more zero groups is compressed to "::" and all groups
+// are printed in lower-case hex. IPv4-mapped addresses are printed in pure hex
+// as well (e.g., ::ffff:c000:201). Use StringMapped for the dotted-quad form.
 func (ip IPv6Addr) String() string {
-	var buf bytes.Buffer
-	zeros := 0
-	for i := 0; i < 16; i += 2 {
-		if ip[i] == 0 && ip[i+1] == 0 {
-			zeros++
-			if zeros == 2 {
-				if zeros == i {
-					buf.WriteString("::")
-				} else {
-					buf.WriteString(":")
-				}
-			}
+	var buf [39]byte
+	return string(ip.AppendTo(buf[:0]))
+}
+
+// AppendTo appends the textual form of ip, as returned by String, to b and
+// returns the extended buffer. See MACAddr.AppendTo.
+func (ip IPv6Addr) AppendTo(b []byte) []byte {
+	return ip.appendGroups(b, 8)
+}
+
+// StringMapped is like String but prints IPv4-mapped addresses with a
+// dotted-quad tail (e.g., ::ffff:192.0.2.1). Other addresses are printed
+// exactly as String prints them.
+func (ip IPv6Addr) StringMapped() string {
+	for i := 0; i < 10; i++ {
+		if ip[i] != 0 {
+			return ip.String()
+		}
+	}
+	if ip[10] != 0xFF || ip[11] != 0xFF {
+		return ip.String()
+	}
+
+	var buf [45]byte
+	b := append(ip.appendGroups(buf[:0], 6), ':')
+	return string(IPv4Addr{ip[12], ip[13], ip[14], ip[15]}.AppendTo(b))
+}
+
+// StringExpanded returns the full, uncompressed form of ip with all 8 groups
+// printed as 4 hex digits; e.g., 2001:0db8:0000:0000:0000:0000:0000:0001. This
+// is needed for devices that do not accept "::".
+func (ip IPv6Addr) StringExpanded() string {
+	return fmt.Sprintf("%02x%02x:%02x%02x:%02x%02x:%02x%02x:"+
+		"%02x%02x:%02x%02x:%02x%02x:%02x%02x", ip[0], ip[1], ip[2], ip[3], ip[4],
+		ip[5], ip[6], ip[7], ip[8], ip[9], ip[10], ip[11], ip[12], ip[13], ip[14],
+		ip[15])
+}
+
+// StringMaxWidth is like String but, if the result is longer than n
+// characters, it replaces the middle of the address with "…" so that the
+// result is exactly n characters wide; eg, "2001:d…:7334" for
+// 2001:db8:85a3::8a2e:370:7334 and n=12. The first and the last groups
+// remain readable for n of 9 or more. This is meant for fixed-width displays
+// only, and the result cannot be parsed back.
+func (ip IPv6Addr) StringMaxWidth(n int) string {
+	s := ip.String()
+	switch {
+	case len(s) <= n:
+		return s
+	case n <= 0:
+		return ""
+	}
+	head := n / 2
+	tail := n - 1 - head
+	return s[:head] + "…" + s[len(s)-tail:]
+}
+
+// appendGroups appends the first n 16-bit groups of ip to b, compressing the
+// longest run of zero groups.
+func (ip IPv6Addr) appendGroups(b []byte, n int) []byte {
+	start, length := -1, 1
+	for i := 0; i < n; {
+		if ip[2*i] != 0 || ip[2*i+1] != 0 {
+			i++
 			continue
 		}
-		if zeros == 1 {
-			buf.WriteString("0:")
+		j := i
+		for j < n && ip[2*j] == 0 && ip[2*j+1] == 0 {
+			j++
 		}
-		buf.WriteString(fmt.Sprintf("%x", int(ip[i])<<8|int(ip[i+1])))
-		if i != 14 {
-			buf.WriteString(":")
+		if j-i > length {
+			start, length = i, j-i
 		}
-		zeros = 0
+		i = j
 	}
-	return buf.String()
+
+	for i := 0; i < n; i++ {
+		if i == start {
+			b = append(b, "::"...)
+			i += length - 1
+			continue
+		}
+		if i != 0 && i != start+length {
+			b = append(b, ':')
+		}
+		b = strconv.AppendUint(b, uint64(ip[2*i])<<8|uint64(ip[2*i+1]), 16)
+	}
+	return b
 }
 
 // AsCIDRMask returns the CIDR prefix number based on this address.
@@ -278,15 +1013,119 @@ func (ip IPv6Addr) AsCIDRMask() int {
 	return 0
 }
 
+// MaskFromPrefixLen6 returns the IPv6 mask of a prefix of length l. For
+// example, it returns ffff:ffff:: for 32.
+func MaskFromPrefixLen6(l int) IPv6Addr {
+	var mask IPv6Addr
+	copy(mask[:], addrBits(MaskNoneIPV6[:]).Masked(l))
+	return mask
+}
+
+// IsValidNetmask6 returns whether mask is a contiguous IPv6 netmask.
+func IsValidNetmask6(mask IPv6Addr) bool {
+	return mask == MaskFromPrefixLen6(mask.AsCIDRMask())
+}
+
 // MaskedIPv6Addr represents a masked IPv6 address.
 type MaskedIPv6Addr struct {
 	Addr IPv6Addr
 	Mask IPv6Addr
 }
 
+// DefaultRouteIPv6 is the IPv6 default route, ::/0.
+var DefaultRouteIPv6 = MaskedIPv6Addr{}
+
+// IsDefaultRoute returns whether the prefix is a default route; ie, whether
+// its mask is all zeros.
+func (mi MaskedIPv6Addr) IsDefaultRoute() bool {
+	return mi.Mask == IPv6Addr{}
+}
+
+// AnyIPv6 is the masked address that matches every IPv6 address. See AnyIPv4.
+var AnyIPv6 = MaskedIPv6Addr{}
+
+// IsAny returns whether the masked address matches every IPv6 address; ie,
+// whether its mask is all zeros.
+func (mi MaskedIPv6Addr) IsAny() bool {
+	return mi.Mask == IPv6Addr{}
+}
+
 // Match returns whether the masked IP address matches ip.
 func (mi MaskedIPv6Addr) Match(ip IPv6Addr) bool {
-	return mi.Addr.Mask(mi.Mask) == ip.Mask(mi.Mask)
+	return mi.MatchUint64s(ip.Uint64s())
+}
+
+// MatchErr is like Match but accepts any address, and returns
+// ErrFamilyMismatch if a is not an IPv6 address. See MaskedMACAddr.MatchErr.
+func (mi MaskedIPv6Addr) MatchErr(a Addr) (bool, error) {
+	ip, ok := a.(IPv6Addr)
+	if !ok {
+		return false, ErrFamilyMismatch
+	}
+	return mi.Match(ip), nil
+}
+
+// Family returns AddrFamilyIPv6.
+func (mi MaskedIPv6Addr) Family() AddrFamily {
+	return AddrFamilyIPv6
+}
+
+// MatchUint64s is like Match but accepts the IP address in the integer form
+// returned by IPv6Addr.Uint64s.
+func (mi MaskedIPv6Addr) MatchUint64s(hi, lo uint64) bool {
+	ahi, alo := mi.Addr.Uint64s()
+	mhi, mlo := mi.Mask.Uint64s()
+	return ahi&mhi == hi&mhi && alo&mlo == lo&mlo
+}
+
+// PrefixLen returns the length of the prefix. For example, it returns 32 for
+// 2001:db8::/32.
+func (mi MaskedIPv6Addr) PrefixLen() int {
+	return mi.Mask.AsCIDRMask()
+}
+
+// IsHostRoute returns whether the prefix is a /128, ie, whether it matches
+// only Addr. See MaskedIPv4Addr.IsHostRoute.
+func (mi MaskedIPv6Addr) IsHostRoute() bool {
+	return mi.PrefixLen() == 128 && IsValidNetmask6(mi.Mask)
+}
+
+// IsNibbleAligned returns whether the mask is contiguous and its prefix length
+// is a multiple of 4; ie, whether the prefix ends on a hex digit boundary, as
+// needed for ip6.arpa reverse zones.
+func (mi MaskedIPv6Addr) IsNibbleAligned() bool {
+	return mi.PrefixLen()%4 == 0 && IsValidNetmask6(mi.Mask)
+}
+
+// Key returns a compact string representation of the prefix suitable to store
+// in dictionaries. Host bits are ignored, so that equivalent prefixes always
+// have the same key.
+func (mi MaskedIPv6Addr) Key() string {
+	addr := mi.Addr.Mask(mi.Mask)
+	return maskedKey(addr[:], mi.Mask[:])
+}
+
+// Network returns the network address of the prefix; ie, Addr with its host
+// bits cleared.
+func (mi MaskedIPv6Addr) Network() IPv6Addr {
+	return mi.Addr.Mask(mi.Mask)
+}
+
+// SameSubnet returns whether mi and thatmi represent the same subnet,
+// regardless of their host bits.
+func (mi MaskedIPv6Addr) SameSubnet(thatmi MaskedIPv6Addr) bool {
+	return mi.Mask == thatmi.Mask && mi.Network() == thatmi.Network()
+}
+
+// IsCanonical returns whether the prefix has no host bits set; ie, whether
+// Addr is aligned on the prefix boundary.
+func (mi MaskedIPv6Addr) IsCanonical() bool {
+	return mi.Addr == mi.Addr.Mask(mi.Mask)
+}
+
+// Canonicalize returns a copy of the prefix with its host bits cleared.
+func (mi MaskedIPv6Addr) Canonicalize() MaskedIPv6Addr {
+	return MaskedIPv6Addr{Addr: mi.Addr.Mask(mi.Mask), Mask: mi.Mask}
 }
 
 func (mi MaskedIPv6Addr) Subsumes(thatmi MaskedIPv6Addr) bool {
@@ -296,10 +1135,60 @@ func (mi MaskedIPv6Addr) Subsumes(thatmi MaskedIPv6Addr) bool {
 	return mi.Addr.Mask(mi.Mask) == thatmi.Addr.Mask(mi.Mask)
 }
 
+// Overlaps returns whether mi and thatmi have any address in common. See
+// MaskedIPv4Addr.Overlaps.
+func (mi MaskedIPv6Addr) Overlaps(thatmi MaskedIPv6Addr) bool {
+	for i := range mi.Addr {
+		if (mi.Addr[i]^thatmi.Addr[i])&mi.Mask[i]&thatmi.Mask[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WithinSpace returns whether the prefix is entirely inside space. See
+// MaskedIPv4Addr.WithinSpace.
+func (mi MaskedIPv6Addr) WithinSpace(space MaskedIPv6Addr) bool {
+	return space.Subsumes(mi)
+}
+
+// Less orders masked addresses by their network address and then by their
+// mask. See MaskedIPv4Addr.Less.
+func (mi MaskedIPv6Addr) Less(thatmi MaskedIPv6Addr) bool {
+	n, thatn := mi.Network(), thatmi.Network()
+	if n != thatn {
+		return n.Less(thatn)
+	}
+	if mi.Mask != thatmi.Mask {
+		return mi.Mask.Less(thatmi.Mask)
+	}
+	return mi.Addr.Less(thatmi.Addr)
+}
+
 func (mi MaskedIPv6Addr) String() string {
 	return fmt.Sprintf("%v/%d", mi.Addr, mi.Mask.AsCIDRMask())
 }
 
+func maskedKey(addr, mask []byte) string {
+	b := make([]byte, 0, len(addr)+len(mask))
+	b = append(b, addr...)
+	return string(append(b, mask...))
+}
+
+// hash32 computes the 32-bit FNV-1a hash of b without allocating.
+func hash32(b []byte) uint32 {
+	const (
+		offset = 2166136261
+		prime  = 16777619
+	)
+	h := uint32(offset)
+	for _, c := range b {
+		h ^= uint32(c)
+		h *= prime
+	}
+	return h
+}
+
 func init() {
 	gob.Register(IPv4Addr{})
 	gob.Register(IPv6Addr{})
@@ -1,6 +1,11 @@
 package nom
 
-import "testing"
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+)
 
 func TestIPv4String(t *testing.T) {
 	addrs := map[IPv4Addr]string{
@@ -104,3 +109,690 @@ func TestMaskedIPv6String(t *testing.T) {
 		}
 	}
 }
+
+func TestIPv6StringMapped(t *testing.T) {
+	addrs := map[IPv6Addr][2]string{
+		IPv6Addr{10: 0xFF, 11: 0xFF}: {"::ffff:0:0", "::ffff:0.0.0.0"},
+		IPv6Addr{10: 0xFF, 11: 0xFF, 12: 192, 14: 2, 15: 1}: {
+			"::ffff:c000:201",
+			"::ffff:192.0.2.1",
+		},
+		IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 1}: {"2001:db8::1", "2001:db8::1"},
+	}
+	for a, s := range addrs {
+		if a.String() != s[0] {
+			t.Errorf("invalid string for ipv6 address: actual=%v want=%v",
+				a.String(), s[0])
+		}
+		if a.StringMapped() != s[1] {
+			t.Errorf("invalid mapped string for ipv6 address: actual=%v want=%v",
+				a.StringMapped(), s[1])
+		}
+	}
+}
+
+func TestIPv6StringMaxWidth(t *testing.T) {
+	ip, _ := ParseIPv6("2001:db8:85a3::8a2e:370:7334")
+	tests := map[int]string{
+		40: "2001:db8:85a3::8a2e:370:7334",
+		28: "2001:db8:85a3::8a2e:370:7334",
+		27: "2001:db8:85a3…8a2e:370:7334",
+		12: "2001:d…:7334",
+		9:  "2001…7334",
+		1:  "…",
+		0:  "",
+	}
+	for n, want := range tests {
+		s := ip.StringMaxWidth(n)
+		if s != want {
+			t.Errorf("invalid string of width %d: actual=%q want=%q", n, s, want)
+		}
+		if w := utf8.RuneCountInString(s); n <= 28 && w != n {
+			t.Errorf("invalid width of %q: actual=%v want=%v", s, w, n)
+		}
+	}
+}
+
+func TestMaskedIPv4Match(t *testing.T) {
+	prefixes := []MaskedIPv4Addr{
+		CIDRToMaskedIPv4(0x0A000000, 8),
+		CIDRToMaskedIPv4(0xC0A80100, 24),
+		CIDRToMaskedIPv4(0, 0),
+		{Addr: IPv4Addr{10, 0, 0, 1}, Mask: IPv4Addr{255, 0, 255, 0}},
+	}
+	ips := []IPv4Addr{
+		{10, 1, 2, 3},
+		{10, 2, 0, 9},
+		{192, 168, 1, 7},
+		{192, 168, 2, 7},
+	}
+	for _, p := range prefixes {
+		for _, ip := range ips {
+			want := p.Addr.Mask(p.Mask) == ip.Mask(p.Mask)
+			if p.Match(ip) != want {
+				t.Errorf("invalid match of %v against %v: actual=%v want=%v", ip,
+					p, p.Match(ip), want)
+			}
+			if p.MatchUint32(ip.Uint32()) != want {
+				t.Errorf("invalid uint32 match of %v against %v: actual=%v want=%v",
+					ip, p, p.MatchUint32(ip.Uint32()), want)
+			}
+		}
+	}
+}
+
+func BenchmarkMaskedIPv4Match(b *testing.B) {
+	p := CIDRToMaskedIPv4(0xC0A80100, 24)
+	ip := IPv4Addr{192, 168, 1, 7}
+	for i := 0; i < b.N; i++ {
+		p.Match(ip)
+	}
+}
+
+func BenchmarkMaskedIPv4MatchBytes(b *testing.B) {
+	p := CIDRToMaskedIPv4(0xC0A80100, 24)
+	ip := IPv4Addr{192, 168, 1, 7}
+	for i := 0; i < b.N; i++ {
+		_ = p.Addr.Mask(p.Mask) == ip.Mask(p.Mask)
+	}
+}
+
+func maskIPv6Bytes(ip, mask IPv6Addr) IPv6Addr {
+	for i := range ip {
+		ip[i] &= mask[i]
+	}
+	return ip
+}
+
+func randIPv6(r *rand.Rand) IPv6Addr {
+	var ip IPv6Addr
+	r.Read(ip[:])
+	return ip
+}
+
+func TestMaskedIPv6Match(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := MaskedIPv6Addr{Addr: randIPv6(r), Mask: randIPv6(r)}
+		ip := randIPv6(r)
+		if i%2 == 0 {
+			// Make sure we also exercise addresses that match.
+			ip = maskIPv6Bytes(p.Addr, p.Mask)
+		}
+		if p.Addr.Mask(p.Mask) != maskIPv6Bytes(p.Addr, p.Mask) {
+			t.Errorf("invalid mask of %v with %v", p.Addr, p.Mask)
+		}
+		want := maskIPv6Bytes(p.Addr, p.Mask) == maskIPv6Bytes(ip, p.Mask)
+		if p.Match(ip) != want {
+			t.Errorf("invalid match of %v against %v: actual=%v want=%v", ip, p,
+				p.Match(ip), want)
+		}
+		if p.MatchUint64s(ip.Uint64s()) != want {
+			t.Errorf("invalid uint64 match of %v against %v: actual=%v want=%v",
+				ip, p, p.MatchUint64s(ip.Uint64s()), want)
+		}
+	}
+}
+
+func BenchmarkMaskedIPv6Match(b *testing.B) {
+	p := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8},
+		Mask: IPv6Addr{0xFF, 0xFF, 0xFF, 0xFF},
+	}
+	ip := IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 1}
+	for i := 0; i < b.N; i++ {
+		p.Match(ip)
+	}
+}
+
+func BenchmarkMaskedIPv6MatchBytes(b *testing.B) {
+	p := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8},
+		Mask: IPv6Addr{0xFF, 0xFF, 0xFF, 0xFF},
+	}
+	ip := IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 1}
+	for i := 0; i < b.N; i++ {
+		_ = maskIPv6Bytes(p.Addr, p.Mask) == maskIPv6Bytes(ip, p.Mask)
+	}
+}
+
+func TestMaskedKey(t *testing.T) {
+	p1 := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 1},
+		Mask: IPv4Addr{255, 255, 255, 0},
+	}
+	p2 := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 2},
+		Mask: IPv4Addr{255, 255, 255, 0},
+	}
+	p3 := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 2},
+		Mask: IPv4Addr{255, 255, 0, 0},
+	}
+	if p1.Key() != p2.Key() {
+		t.Errorf("%v and %v should have the same key", p1, p2)
+	}
+	if p1.Key() == p3.Key() {
+		t.Errorf("%v and %v should have different keys", p1, p3)
+	}
+
+	m1 := MaskedIPv6Addr{Addr: IPv6Addr{0x20, 0x01, 15: 1}, Mask: IPv6Addr{0xFF}}
+	m2 := MaskedIPv6Addr{Addr: IPv6Addr{0x20, 0x02}, Mask: IPv6Addr{0xFF}}
+	if m1.Key() != m2.Key() {
+		t.Errorf("%v and %v should have the same key", m1, m2)
+	}
+
+	e1 := MaskedMACAddr{Addr: MACAddr{1, 2, 3, 4}, Mask: MACAddr{0xFF}}
+	e2 := MaskedMACAddr{Addr: MACAddr{1, 5, 6}, Mask: MACAddr{0xFF}}
+	if e1.Key() != e2.Key() {
+		t.Errorf("%v and %v should have the same key", e1, e2)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	p := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 1},
+		Mask: IPv4Addr{255, 255, 255, 0},
+	}
+	if p.IsCanonical() {
+		t.Errorf("%v should not be canonical", p)
+	}
+	c := p.Canonicalize()
+	if !c.IsCanonical() || c.Addr != (IPv4Addr{10, 0, 0, 0}) {
+		t.Errorf("invalid canonical form of %v: %v", p, c)
+	}
+
+	p6 := MaskedIPv6Addr{Addr: IPv6Addr{0x20, 0x01, 15: 1}, Mask: IPv6Addr{0xFF}}
+	if p6.IsCanonical() {
+		t.Errorf("%v should not be canonical", p6)
+	}
+	if c := p6.Canonicalize(); c.Addr != (IPv6Addr{0x20}) {
+		t.Errorf("invalid canonical form of %v: %v", p6, c)
+	}
+
+	m := MaskedMACAddr{Addr: MACAddr{1, 2, 3, 4, 5, 6}, Mask: MACAddr{0xFF}}
+	if m.IsCanonical() {
+		t.Errorf("%v should not be canonical", m)
+	}
+	if c := m.Canonicalize(); !c.IsCanonical() || c.Addr != (MACAddr{1}) {
+		t.Errorf("invalid canonical form of %v: %v", m, c)
+	}
+}
+
+func TestEqualConstantTime(t *testing.T) {
+	m1 := MACAddr{1, 2, 3, 4, 5, 6}
+	m2 := MACAddr{1, 2, 3, 4, 5, 7}
+	if !m1.EqualConstantTime(m1) || m1.EqualConstantTime(m2) {
+		t.Errorf("invalid constant-time comparison of %v and %v", m1, m2)
+	}
+
+	ip1 := IPv4Addr{10, 0, 0, 1}
+	ip2 := IPv4Addr{10, 0, 0, 2}
+	if !ip1.EqualConstantTime(ip1) || ip1.EqualConstantTime(ip2) {
+		t.Errorf("invalid constant-time comparison of %v and %v", ip1, ip2)
+	}
+
+	ip61 := IPv6Addr{0x20, 0x01, 15: 1}
+	ip62 := IPv6Addr{0x20, 0x01, 15: 2}
+	if !ip61.EqualConstantTime(ip61) || ip61.EqualConstantTime(ip62) {
+		t.Errorf("invalid constant-time comparison of %v and %v", ip61, ip62)
+	}
+}
+
+func TestIPv6StringExpanded(t *testing.T) {
+	addrs := map[IPv6Addr]string{
+		IPv6Addr{}: "0000:0000:0000:0000:0000:0000:0000:0000",
+		IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 1}: "2001:0db8:0000:0000:0000:" +
+			"0000:0000:0001",
+	}
+	for a, s := range addrs {
+		if a.StringExpanded() != s {
+			t.Errorf("invalid expanded string for ipv6 address: actual=%v want=%v",
+				a.StringExpanded(), s)
+		}
+	}
+}
+
+func TestSameSubnet(t *testing.T) {
+	p1 := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 1},
+		Mask: IPv4Addr{255, 255, 255, 0},
+	}
+	p2 := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 200},
+		Mask: IPv4Addr{255, 255, 255, 0},
+	}
+	p3 := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 1},
+		Mask: IPv4Addr{255, 255, 0, 0},
+	}
+	if p1 == p2 || !p1.SameSubnet(p2) {
+		t.Errorf("%v and %v should be the same subnet", p1, p2)
+	}
+	if p1.SameSubnet(p3) {
+		t.Errorf("%v and %v should not be the same subnet", p1, p3)
+	}
+
+	q1 := MaskedIPv6Addr{Addr: IPv6Addr{0x20, 0x01, 15: 1}, Mask: IPv6Addr{0xFF}}
+	q2 := MaskedIPv6Addr{Addr: IPv6Addr{0x20, 0x02}, Mask: IPv6Addr{0xFF}}
+	q3 := MaskedIPv6Addr{Addr: IPv6Addr{0x21, 0x01}, Mask: IPv6Addr{0xFF}}
+	if !q1.SameSubnet(q2) || q1.SameSubnet(q3) {
+		t.Errorf("invalid subnet equality for %v, %v, and %v", q1, q2, q3)
+	}
+
+	m1 := MaskedMACAddr{Addr: MACAddr{1, 2, 3, 4}, Mask: MACAddr{0xFF, 0xFF}}
+	m2 := MaskedMACAddr{Addr: MACAddr{1, 2, 5, 6}, Mask: MACAddr{0xFF, 0xFF}}
+	m3 := MaskedMACAddr{Addr: MACAddr{1, 3, 3, 4}, Mask: MACAddr{0xFF, 0xFF}}
+	if !m1.SameSubnet(m2) || m1.SameSubnet(m3) {
+		t.Errorf("invalid subnet equality for %v, %v, and %v", m1, m2, m3)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	ip := IPv4Addr{10, 1, 2, 3}
+	ips := map[int]IPv4Addr{
+		0:  {},
+		8:  {10, 0, 0, 0},
+		24: {10, 1, 2, 0},
+		31: {10, 1, 2, 2},
+		32: ip,
+	}
+	for l, want := range ips {
+		if tr := ip.Truncate(l); tr != want {
+			t.Errorf("invalid truncation to %d: actual=%v want=%v", l, tr, want)
+		}
+	}
+
+	ip6 := IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	ip6s := map[int]string{
+		0:   "::",
+		32:  "2001:db8::",
+		64:  "2001:db8:ffff:ffff::",
+		72:  "2001:db8:ffff:ffff:ff00::",
+		128: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff",
+	}
+	for l, want := range ip6s {
+		if tr := ip6.Truncate(l); tr.String() != want {
+			t.Errorf("invalid truncation to %d: actual=%v want=%v", l, tr, want)
+		}
+	}
+
+	mac := MACAddr{0x00, 0x1b, 0x21, 0x3a, 0x4b, 0x5c}
+	if tr := mac.Truncate(24); tr != (MACAddr{0x00, 0x1b, 0x21}) {
+		t.Errorf("invalid truncation to 24: actual=%v want=00:1b:21:00:00:00", tr)
+	}
+	if tr := mac.Truncate(0); tr != (MACAddr{}) {
+		t.Errorf("invalid truncation to 0: actual=%v want=00:00:00:00:00:00", tr)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ip.Truncate(24)
+		ip6.Truncate(48)
+		mac.Truncate(24)
+	})
+	if allocs != 0 {
+		t.Errorf("truncate should not allocate: allocs=%v", allocs)
+	}
+
+	if tr := ip.Truncate(33); tr != ip {
+		t.Errorf("invalid truncation to 33: actual=%v want=%v", tr, ip)
+	}
+	if tr := ip.Truncate(-1); tr != (IPv4Addr{}) {
+		t.Errorf("invalid truncation to -1: actual=%v want=0.0.0.0", tr)
+	}
+	if tr := ip6.Truncate(129); tr != ip6 {
+		t.Errorf("invalid truncation to 129: actual=%v want=%v", tr, ip6)
+	}
+	if tr := mac.Truncate(-8); tr != (MACAddr{}) {
+		t.Errorf("invalid truncation to -8: actual=%v want=00:00:00:00:00:00", tr)
+	}
+}
+
+func TestGlobString(t *testing.T) {
+	tests := map[MaskedIPv4Addr]string{
+		CIDRToMaskedIPv4(0x0A000000, 8):  "10.*.*.*",
+		CIDRToMaskedIPv4(0x0A010000, 16): "10.1.*.*",
+		CIDRToMaskedIPv4(0x0A010203, 24): "10.1.2.*",
+		CIDRToMaskedIPv4(0x0A010203, 32): "10.1.2.3",
+		CIDRToMaskedIPv4(0, 0):           "*.*.*.*",
+		CIDRToMaskedIPv4(0x0A001000, 20): "10.0.16.0/20",
+	}
+	for mi, want := range tests {
+		if actual := mi.GlobString(); actual != want {
+			t.Errorf("invalid glob string for %v: actual=%v want=%v", mi, actual,
+				want)
+		}
+	}
+}
+
+func TestAlignment(t *testing.T) {
+	tests4 := map[MaskedIPv4Addr]bool{
+		CIDRToMaskedIPv4(0x0A000000, 8):  true,
+		CIDRToMaskedIPv4(0x0A010000, 16): true,
+		CIDRToMaskedIPv4(0, 0):           true,
+		CIDRToMaskedIPv4(0x0A001000, 20): false,
+		CIDRToMaskedIPv4(0x0A000000, 31): false,
+		{Mask: IPv4Addr{255, 0, 255, 0}}: false,
+	}
+	for mi, want := range tests4 {
+		if a := mi.IsOctetAligned(); a != want {
+			t.Errorf("invalid octet alignment of %v: actual=%v want=%v", mi, a,
+				want)
+		}
+	}
+
+	tests6 := map[string]bool{
+		"2001:db8::/32":   true,
+		"2001:db8::/36":   true,
+		"2001:db8::/127":  false,
+		"2001:db8::/49":   false,
+		"2001:db8::1/128": true,
+	}
+	for s, want := range tests6 {
+		mi, _ := ParseCIDRv6(s)
+		if a := mi.IsNibbleAligned(); a != want {
+			t.Errorf("invalid nibble alignment of %v: actual=%v want=%v", s, a,
+				want)
+		}
+	}
+
+	testsMAC := map[MACAddr]bool{
+		{0xFF, 0xFF, 0xFF, 0, 0, 0}:          true,
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}: true,
+		{}:                                   true,
+		{0xFF, 0xFF, 0xF0, 0, 0, 0}:          false,
+		{0xFF, 0x00, 0xFF, 0, 0, 0}:          false,
+	}
+	for mask, want := range testsMAC {
+		mm := MaskedMACAddr{Mask: mask}
+		if a := mm.IsOctetAligned(); a != want {
+			t.Errorf("invalid octet alignment of %v: actual=%v want=%v", mm, a,
+				want)
+		}
+	}
+}
+
+func TestMatchErr(t *testing.T) {
+	v4 := CIDRToMaskedIPv4(0x0A000000, 8)
+	v6 := MaskedIPv6Addr{Mask: MaskFromPrefixLen6(0)}
+	mac := MaskedMACAddr{Mask: BroadcastMAC}
+	for _, tc := range []struct {
+		prefix MaskedAddr
+		addr   Addr
+		match  bool
+		err    error
+	}{
+		{v4, IPv4Addr{10, 1, 2, 3}, true, nil},
+		{v4, IPv4Addr{11, 1, 2, 3}, false, nil},
+		{v4, IPv6Addr{10, 1, 2, 3}, false, ErrFamilyMismatch},
+		{v6, IPv6Addr{}, true, nil},
+		{v6, IPv4Addr{}, false, ErrFamilyMismatch},
+		{mac, MACAddr{}, true, nil},
+		{mac, IPv6Addr{}, false, ErrFamilyMismatch},
+	} {
+		match, err := tc.prefix.MatchErr(tc.addr)
+		if match != tc.match || err != tc.err {
+			t.Errorf("invalid match of %v against %v: actual=%v,%v want=%v,%v",
+				tc.addr, tc.prefix, match, err, tc.match, tc.err)
+		}
+	}
+}
+
+func TestAddrAdd(t *testing.T) {
+	ip4 := IPv4Addr{10, 0, 0, 250}
+	tests4 := map[int64]IPv4Addr{
+		0:       ip4,
+		10:      {10, 0, 1, 4},
+		-251:    {9, 255, 255, 255},
+		1 << 32: ip4,
+	}
+	for n, want := range tests4 {
+		if actual := ip4.Add(n); actual != want {
+			t.Errorf("invalid %v+%d: actual=%v want=%v", ip4, n, actual, want)
+		}
+	}
+	if ip := (IPv4Addr{}).Add(-1); ip != MaskNoneIPV4 {
+		t.Errorf("invalid wrap around: actual=%v want=255.255.255.255", ip)
+	}
+
+	mac := MACAddr{0, 0, 0, 0, 0, 0xFF}
+	if m := mac.Add(2); m != (MACAddr{0, 0, 0, 0, 1, 1}) {
+		t.Errorf("invalid %v+2: %v", mac, m)
+	}
+	if m := mac.Add(-0x100); m != BroadcastMAC {
+		t.Errorf("invalid %v-256: actual=%v want=%v", mac, m, BroadcastMAC)
+	}
+
+	ip6, _ := ParseIPv6("2001:db8::ffff:ffff:ffff:ffff")
+	if ip := ip6.Add(big.NewInt(1)); ip.String() != "2001:db8:0:1::" {
+		t.Errorf("invalid %v+1: actual=%v want=2001:db8:0:1::", ip6, ip)
+	}
+	if ip := ip6.Add(big.NewInt(-0x10000)); ip.String() !=
+		"2001:db8::ffff:ffff:fffe:ffff" {
+		t.Errorf("invalid %v-65536: %v", ip6, ip)
+	}
+	if ip := (IPv6Addr{}).Add(big.NewInt(-1)); ip != MaskNoneIPV6 {
+		t.Errorf("invalid wrap around: actual=%v want=%v", ip, MaskNoneIPV6)
+	}
+}
+
+func TestIsHostRoute(t *testing.T) {
+	nonContiguous := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 1},
+		Mask: IPv4Addr{0, 0, 0, 1},
+	}
+	tests4 := map[MaskedIPv4Addr]bool{
+		CIDRToMaskedIPv4(0x0A000001, 32): true,
+		CIDRToMaskedIPv4(0x0A000001, 31): false,
+		CIDRToMaskedIPv4(0, 0):           false,
+		nonContiguous:                    false,
+	}
+	for mi, want := range tests4 {
+		if actual := mi.IsHostRoute(); actual != want {
+			t.Errorf("invalid host route check for %v/%v: actual=%v want=%v",
+				mi.Addr, mi.Mask, actual, want)
+		}
+	}
+
+	ip, _ := ParseIPv6("2001:db8::1")
+	host := MaskedIPv6Addr{Addr: ip, Mask: MaskFromPrefixLen6(128)}
+	net := MaskedIPv6Addr{Addr: ip, Mask: MaskFromPrefixLen6(64)}
+	if !host.IsHostRoute() || net.IsHostRoute() {
+		t.Errorf("invalid host route check for %v and %v", host, net)
+	}
+
+	mac := MACAddr{0, 0x11, 0x22, 0x33, 0x44, 0x55}
+	exact := MaskedMACAddr{Addr: mac, Mask: MaskNoneMAC}
+	oui := MaskedMACAddr{Addr: mac, Mask: MACAddr{0xFF, 0xFF, 0xFF}}
+	if !exact.IsExact() || oui.IsExact() {
+		t.Errorf("invalid exact check for %v and %v", exact, oui)
+	}
+	if oui.PrefixLen() != 24 || exact.PrefixLen() != 48 {
+		t.Errorf("invalid prefix lengths: %v %v", oui.PrefixLen(),
+			exact.PrefixLen())
+	}
+}
+
+func TestIsAny(t *testing.T) {
+	if !AnyIPv4.IsAny() || !AnyIPv6.IsAny() || !AnyMAC.IsAny() {
+		t.Errorf("any addresses should be any")
+	}
+	if CIDRToMaskedIPv4(0, 1).IsAny() {
+		t.Errorf("0.0.0.0/1 should not be any")
+	}
+	// Host bits do not matter for an all-zero mask.
+	any4 := MaskedIPv4Addr{Addr: IPv4Addr{10, 1, 2, 3}}
+	if !any4.IsAny() {
+		t.Errorf("%v should be any", any4)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var ip4 IPv4Addr
+		var ip6 IPv6Addr
+		var mac MACAddr
+		r.Read(ip4[:])
+		r.Read(ip6[:])
+		r.Read(mac[:])
+		if !AnyIPv4.Match(ip4) || !any4.Match(ip4) || !AnyIPv6.Match(ip6) ||
+			!AnyMAC.Match(mac) {
+			t.Errorf("any should match %v, %v, and %v", ip4, ip6, mac)
+		}
+	}
+}
+
+func TestMACBits(t *testing.T) {
+	m := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	m.SetLocallyAdministered(true)
+	if m[0] != 0x02 || !m.IsLocallyAdministered() || m.IsGroup() {
+		t.Errorf("invalid locally administered address: %v", m)
+	}
+	m.SetMulticast(true)
+	if m[0] != 0x03 || !m.IsGroup() {
+		t.Errorf("invalid multicast address: %v", m)
+	}
+	m.SetLocallyAdministered(false)
+	if m[0] != 0x01 || m.IsLocallyAdministered() {
+		t.Errorf("invalid universal address: %v", m)
+	}
+	m.SetMulticast(false)
+	if m != (MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}) {
+		t.Errorf("invalid address after clearing bits: %v", m)
+	}
+
+	m = MACAddr{0xFC, 0, 0, 0, 0, 0}
+	m.SetMulticast(true)
+	m.SetLocallyAdministered(true)
+	if m[0] != 0xFF {
+		t.Errorf("other bits are modified: actual=%x want=ff", m[0])
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	if MaxMAC.Next() != MinMAC || MaxIPv4.Next() != MinIPv4 ||
+		MaxIPv6.Next() != MinIPv6 {
+		t.Errorf("max addresses should wrap around to min addresses")
+	}
+	if MaxMAC != BroadcastMAC || MaxIPv4 != MaskNoneIPV4 ||
+		MaxIPv6 != MaskNoneIPV6 {
+		t.Errorf("max addresses should be all ones")
+	}
+	if !MinIPv4.Less(MaxIPv4) || !MinIPv6.Less(MaxIPv6) || !MinMAC.Less(MaxMAC) {
+		t.Errorf("min addresses should be less than max addresses")
+	}
+	ip, _ := ParseIPv6("::ffff:ffff:ffff:ffff")
+	if n := ip.Next(); n.String() != "0:0:0:1::" {
+		t.Errorf("invalid next of %v: actual=%v want=0:0:0:1::", ip, n)
+	}
+}
+
+func TestAppendTo(t *testing.T) {
+	ip6, _ := ParseIPv6("2001:db8::1")
+	addrs := []interface {
+		String() string
+		AppendTo(b []byte) []byte
+	}{
+		MACAddr{0x00, 0x1A, 0x2B, 0x3C, 0x4D, 0xFF},
+		IPv4Addr{192, 0, 2, 10},
+		MinIPv4,
+		ip6,
+		MinIPv6,
+		MaxIPv6,
+	}
+	for _, a := range addrs {
+		b := a.AppendTo([]byte("addr="))
+		if want := "addr=" + a.String(); string(b) != want {
+			t.Errorf("invalid append: actual=%q want=%q", b, want)
+		}
+	}
+
+	buf := make([]byte, 0, 64)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = ip6.AppendTo(buf[:0])
+		buf = MaxIPv4.AppendTo(buf[:0])
+		buf = BroadcastMAC.AppendTo(buf[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("AppendTo allocates %v times", allocs)
+	}
+}
+
+func BenchmarkIPv6String(b *testing.B) {
+	ip, _ := ParseIPv6("2001:db8::1:2")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ip.String()
+	}
+}
+
+func BenchmarkIPv6AppendTo(b *testing.B) {
+	ip, _ := ParseIPv6("2001:db8::1:2")
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = ip.AppendTo(buf[:0])
+	}
+}
+
+func TestRegisterLLDPMulticast(t *testing.T) {
+	custom := MACAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x03}
+	if custom.IsLLDP() {
+		t.Fatalf("%v should not be an LLDP address by default", custom)
+	}
+	RegisterLLDPMulticast(custom)
+	defer delete(customLLDP.macs, custom)
+	if !custom.IsLLDP() {
+		t.Errorf("registered %v should be an LLDP address", custom)
+	}
+	for _, m := range LLDPMulticastMACs {
+		if !m.IsLLDP() {
+			t.Errorf("%v should still be an LLDP address", m)
+		}
+	}
+	if (MACAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x04}).IsLLDP() {
+		t.Errorf("unregistered address is recognized as LLDP")
+	}
+}
+
+func TestNetworkWith(t *testing.T) {
+	ip := IPv4Addr{10, 1, 2, 3}
+	tests := map[int]IPv4Addr{0: {}, 8: {10, 0, 0, 0}, 24: {10, 1, 2, 0}, 32: ip}
+	for l, want := range tests {
+		if n, err := ip.NetworkWith(l); err != nil || n != want {
+			t.Errorf("invalid network of %v/%d: actual=%v want=%v err=%v", ip, l, n,
+				want, err)
+		}
+	}
+
+	ip6, _ := ParseIPv6("2001:db8::1")
+	if n, err := ip6.NetworkWith(0); err != nil || n != MinIPv6 {
+		t.Errorf("invalid network of %v/0: %v err=%v", ip6, n, err)
+	}
+	if n, err := ip6.NetworkWith(128); err != nil || n != ip6 {
+		t.Errorf("invalid network of %v/128: %v err=%v", ip6, n, err)
+	}
+	if n, _ := ip6.NetworkWith(32); n.String() != "2001:db8::" {
+		t.Errorf("invalid network of %v/32: %v", ip6, n)
+	}
+
+	mac := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	if n, err := mac.NetworkWith(0); err != nil || n != MinMAC {
+		t.Errorf("invalid network of %v/0: %v err=%v", mac, n, err)
+	}
+	if n, err := mac.NetworkWith(48); err != nil || n != mac {
+		t.Errorf("invalid network of %v/48: %v err=%v", mac, n, err)
+	}
+
+	for _, l := range []int{-1, 33} {
+		if _, err := ip.NetworkWith(l); err == nil {
+			t.Errorf("no error for IPv4 prefix length %d", l)
+		}
+	}
+	if _, err := ip6.NetworkWith(129); err == nil {
+		t.Errorf("no error for IPv6 prefix length 129")
+	}
+	if _, err := mac.NetworkWith(49); err == nil {
+		t.Errorf("no error for MAC prefix length 49")
+	}
+}
@@ -0,0 +1,79 @@
+package nom
+
+var (
+	ipv4Loopback  = CIDRToMaskedIPv4(0x7F000000, 8)
+	ipv4LinkLocal = CIDRToMaskedIPv4(0xA9FE0000, 16)
+	ipv4Multicast = CIDRToMaskedIPv4(0xE0000000, 4)
+	ipv4Private   = []MaskedIPv4Addr{
+		CIDRToMaskedIPv4(0x0A000000, 8),
+		CIDRToMaskedIPv4(0xAC100000, 12),
+		CIDRToMaskedIPv4(0xC0A80000, 16),
+	}
+	ipv4Reserved = []MaskedIPv4Addr{
+		CIDRToMaskedIPv4(0x00000000, 8),  // "This" network.
+		CIDRToMaskedIPv4(0x64400000, 10), // Shared address space.
+		CIDRToMaskedIPv4(0xC0000000, 24), // IETF protocol assignments.
+		CIDRToMaskedIPv4(0xC0000200, 24), // TEST-NET-1.
+		CIDRToMaskedIPv4(0xC6120000, 15), // Benchmarking.
+		CIDRToMaskedIPv4(0xC6336400, 24), // TEST-NET-2.
+		CIDRToMaskedIPv4(0xCB007100, 24), // TEST-NET-3.
+		CIDRToMaskedIPv4(0xF0000000, 4),  // Future use.
+	}
+)
+
+// IsUnspecified returns whether ip is 0.0.0.0.
+func (ip IPv4Addr) IsUnspecified() bool {
+	return ip == IPv4Addr{}
+}
+
+// IsBroadcast returns whether ip is the limited broadcast address,
+// 255.255.255.255.
+func (ip IPv4Addr) IsBroadcast() bool {
+	return ip == MaskNoneIPV4
+}
+
+// IsLoopback returns whether ip is in 127.0.0.0/8.
+func (ip IPv4Addr) IsLoopback() bool {
+	return ipv4Loopback.Match(ip)
+}
+
+// IsLinkLocal returns whether ip is in 169.254.0.0/16.
+func (ip IPv4Addr) IsLinkLocal() bool {
+	return ipv4LinkLocal.Match(ip)
+}
+
+// IsMulticast returns whether ip is in 224.0.0.0/4.
+func (ip IPv4Addr) IsMulticast() bool {
+	return ipv4Multicast.Match(ip)
+}
+
+// IsPrivate returns whether ip is in one of the private address spaces of RFC
+// 1918: 10.0.0.0/8, 172.16.0.0/12, or 192.168.0.0/16.
+func (ip IPv4Addr) IsPrivate() bool {
+	return matchAnyIPv4(ipv4Private, ip)
+}
+
+// IsReserved returns whether ip is in one of the special-purpose address
+// blocks of RFC 6890 that are not covered by the other predicates, such as
+// the documentation networks and 240.0.0.0/4.
+func (ip IPv4Addr) IsReserved() bool {
+	return matchAnyIPv4(ipv4Reserved, ip)
+}
+
+// IsGlobalUnicast returns whether ip is a routable unicast address; ie, it is
+// none of loopback, private, link-local, multicast, unspecified, broadcast,
+// or reserved.
+func (ip IPv4Addr) IsGlobalUnicast() bool {
+	return !ip.IsUnspecified() && !ip.IsBroadcast() && !ip.IsLoopback() &&
+		!ip.IsLinkLocal() && !ip.IsMulticast() && !ip.IsPrivate() &&
+		!ip.IsReserved()
+}
+
+func matchAnyIPv4(prefixes []MaskedIPv4Addr, ip IPv4Addr) bool {
+	for _, p := range prefixes {
+		if p.Match(ip) {
+			return true
+		}
+	}
+	return false
+}
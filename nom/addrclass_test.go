@@ -0,0 +1,27 @@
+package nom
+
+import "testing"
+
+func TestIPv4IsGlobalUnicast(t *testing.T) {
+	addrs := map[IPv4Addr]bool{
+		IPv4Addr{0, 0, 0, 0}:         false, // Unspecified.
+		IPv4Addr{255, 255, 255, 255}: false, // Broadcast.
+		IPv4Addr{127, 0, 0, 1}:       false, // Loopback.
+		IPv4Addr{10, 1, 2, 3}:        false, // Private.
+		IPv4Addr{172, 16, 0, 1}:      false, // Private.
+		IPv4Addr{192, 168, 1, 1}:     false, // Private.
+		IPv4Addr{169, 254, 1, 1}:     false, // Link-local.
+		IPv4Addr{224, 0, 0, 5}:       false, // Multicast.
+		IPv4Addr{192, 0, 2, 1}:       false, // Reserved.
+		IPv4Addr{240, 0, 0, 1}:       false, // Reserved.
+		IPv4Addr{8, 8, 8, 8}:         true,
+		IPv4Addr{172, 32, 0, 1}:      true,
+		IPv4Addr{193, 0, 2, 1}:       true,
+	}
+	for ip, want := range addrs {
+		if ip.IsGlobalUnicast() != want {
+			t.Errorf("invalid global unicast for %v: actual=%v want=%v", ip,
+				ip.IsGlobalUnicast(), want)
+		}
+	}
+}
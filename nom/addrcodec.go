@@ -0,0 +1,193 @@
+package nom
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// AppendAddr appends a to b using a compact binary framing and returns the
+// extended buffer. The address is encoded as a one-byte family tag (the value
+// of a.Family()) followed by the raw bytes of the address. Use ConsumeAddr to
+// decode it.
+func AppendAddr(b []byte, a Addr) []byte {
+	b = append(b, byte(a.Family()))
+	return append(b, a.Key()...)
+}
+
+// ConsumeAddr decodes an address encoded by AppendAddr from the head of b, and
+// returns the address along with the rest of the buffer. On error, b is
+// returned unchanged.
+func ConsumeAddr(b []byte) (Addr, []byte, error) {
+	if len(b) == 0 {
+		return nil, b, fmt.Errorf("no address in an empty buffer")
+	}
+
+	f := AddrFamily(b[0])
+	var l int
+	switch f {
+	case AddrFamilyMAC:
+		l = MACLen
+	case AddrFamilyIPv4:
+		l = IPv4Len
+	case AddrFamilyIPv6:
+		l = IPv6Len
+	default:
+		return nil, b, fmt.Errorf("invalid address family %v", f)
+	}
+
+	if len(b)-1 < l {
+		return nil, b, fmt.Errorf("%v address needs %d bytes, %d available", f, l,
+			len(b)-1)
+	}
+	b = b[1:]
+
+	var a Addr
+	switch f {
+	case AddrFamilyMAC:
+		var mac MACAddr
+		copy(mac[:], b)
+		a = mac
+	case AddrFamilyIPv4:
+		var ip IPv4Addr
+		copy(ip[:], b)
+		a = ip
+	case AddrFamilyIPv6:
+		var ip IPv6Addr
+		copy(ip[:], b)
+		a = ip
+	}
+	return a, b[l:], nil
+}
+
+// IPv4FromBytes returns the IPv4 address stored in b, which must be exactly 4
+// bytes long. It is safer than converting off-the-wire buffers of uncertain
+// length manually.
+func IPv4FromBytes(b []byte) (IPv4Addr, error) {
+	var ip IPv4Addr
+	if len(b) != IPv4Len {
+		return ip, fmt.Errorf("IPv4 address needs %d bytes, got %d", IPv4Len,
+			len(b))
+	}
+	copy(ip[:], b)
+	return ip, nil
+}
+
+// IPv6FromBytes returns the IPv6 address stored in b, which must be exactly 16
+// bytes long.
+func IPv6FromBytes(b []byte) (IPv6Addr, error) {
+	var ip IPv6Addr
+	if len(b) != IPv6Len {
+		return ip, fmt.Errorf("IPv6 address needs %d bytes, got %d", IPv6Len,
+			len(b))
+	}
+	copy(ip[:], b)
+	return ip, nil
+}
+
+// ToInt64 returns ip as a non-negative integer, eg, to store it in a BIGINT
+// column of a SQL database. It is the same value as Uint32.
+func (ip IPv4Addr) ToInt64() int64 {
+	return int64(ip.Uint32())
+}
+
+// IPv4FromInt64 returns the IPv4 address encoded by ToInt64. It returns an
+// error if n is not in [0, 2^32).
+func IPv4FromInt64(n int64) (IPv4Addr, error) {
+	var ip IPv4Addr
+	if n < 0 || n > math.MaxUint32 {
+		return ip, fmt.Errorf("%d is not a valid IPv4 address", n)
+	}
+	ip.FromUint(uint32(n))
+	return ip, nil
+}
+
+// ToBinary returns the 16 bytes of ip in network order, eg, to store it in a
+// BINARY(16) column of a SQL database. Use IPv6FromBytes to decode it.
+func (ip IPv6Addr) ToBinary() []byte {
+	b := make([]byte, IPv6Len)
+	copy(b, ip[:])
+	return b
+}
+
+// DecimalString returns ip as the decimal representation of the unsigned
+// 128-bit big-endian integer formed by its bytes; eg, "1" for ::1 and
+// "42540766411282592856903984951653826561" for 2001:db8::1. This suits
+// databases that store IPv6 addresses as DECIMAL(39) or NUMERIC values.
+func (ip IPv6Addr) DecimalString() string {
+	return new(big.Int).SetBytes(ip[:]).String()
+}
+
+// IPv6FromDecimal returns the IPv6 address encoded by DecimalString. It
+// returns an error if s is not a decimal integer in [0, 2^128).
+func IPv6FromDecimal(s string) (IPv6Addr, error) {
+	var ip IPv6Addr
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.Cmp(ipv6Modulus) >= 0 {
+		return ip, fmt.Errorf("%q is not a valid IPv6 address", s)
+	}
+	b := n.Bytes()
+	copy(ip[IPv6Len-len(b):], b)
+	return ip, nil
+}
+
+// MACFromBytes returns the MAC address stored in b, which must be exactly 6
+// bytes long.
+func MACFromBytes(b []byte) (MACAddr, error) {
+	var mac MACAddr
+	if len(b) != MACLen {
+		return mac, fmt.Errorf("MAC address needs %d bytes, got %d", MACLen,
+			len(b))
+	}
+	copy(mac[:], b)
+	return mac, nil
+}
+
+// MarshalCompact encodes the prefix into 5 bytes: the 4 bytes of the network
+// address followed by the prefix length. This is a space-efficient format to
+// exchange routes, but it can only encode contiguous masks.
+func (mi MaskedIPv4Addr) MarshalCompact() ([5]byte, error) {
+	var b [5]byte
+	if !IsValidNetmask4(mi.Mask) {
+		return b, fmt.Errorf("%v has a non-contiguous mask %v", mi.Addr, mi.Mask)
+	}
+	n := mi.Network()
+	copy(b[:], n[:])
+	b[4] = byte(mi.PrefixLen())
+	return b, nil
+}
+
+// UnmarshalCompact decodes a prefix encoded by MarshalCompact.
+func (mi *MaskedIPv4Addr) UnmarshalCompact(b [5]byte) error {
+	if b[4] > 32 {
+		return fmt.Errorf("invalid IPv4 prefix length %d", b[4])
+	}
+	copy(mi.Addr[:], b[:4])
+	mi.Mask = MaskFromPrefixLen4(int(b[4]))
+	mi.Addr = mi.Network()
+	return nil
+}
+
+// MarshalCompact encodes the prefix into 17 bytes: the 16 bytes of the network
+// address followed by the prefix length. It can only encode contiguous masks.
+func (mi MaskedIPv6Addr) MarshalCompact() ([17]byte, error) {
+	var b [17]byte
+	if !IsValidNetmask6(mi.Mask) {
+		return b, fmt.Errorf("%v has a non-contiguous mask %v", mi.Addr, mi.Mask)
+	}
+	n := mi.Network()
+	copy(b[:], n[:])
+	b[16] = byte(mi.PrefixLen())
+	return b, nil
+}
+
+// UnmarshalCompact decodes a prefix encoded by MarshalCompact.
+func (mi *MaskedIPv6Addr) UnmarshalCompact(b [17]byte) error {
+	if b[16] > 128 {
+		return fmt.Errorf("invalid IPv6 prefix length %d", b[16])
+	}
+	copy(mi.Addr[:], b[:16])
+	mi.Mask = MaskFromPrefixLen6(int(b[16]))
+	mi.Addr = mi.Network()
+	return nil
+}
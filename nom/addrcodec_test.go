@@ -0,0 +1,182 @@
+package nom
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAddrCodec(t *testing.T) {
+	addrs := []Addr{
+		MACAddr{0x01, 0x02, 0x03, 0x04, 0x05, 0x06},
+		IPv4Addr{10, 0, 0, 1},
+		IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 1},
+		IPv4Addr{192, 168, 0, 1},
+	}
+	var b []byte
+	for _, a := range addrs {
+		b = AppendAddr(b, a)
+	}
+	if l := 4 + MACLen + 2*IPv4Len + IPv6Len; len(b) != l {
+		t.Errorf("invalid encoded length: actual=%v want=%v", len(b), l)
+	}
+
+	for _, want := range addrs {
+		var a Addr
+		var err error
+		a, b, err = ConsumeAddr(b)
+		if err != nil {
+			t.Fatalf("cannot decode %v: %v", want, err)
+		}
+		if a != want {
+			t.Errorf("invalid decoded address: actual=%v want=%v", a, want)
+		}
+	}
+	if len(b) != 0 {
+		t.Errorf("buffer is not consumed: %v", b)
+	}
+
+	for _, in := range [][]byte{{byte(AddrFamilyIPv6), 1, 2}, {0xFF}, {}} {
+		_, rest, err := ConsumeAddr(in)
+		if err == nil {
+			t.Errorf("no error for %v", in)
+		}
+		if !bytes.Equal(rest, in) {
+			t.Errorf("invalid rest on error: actual=%v want=%v", rest, in)
+		}
+	}
+}
+
+func TestMaskedIPv4Compact(t *testing.T) {
+	p := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 1, 2, 3},
+		Mask: IPv4Addr{255, 255, 0, 0},
+	}
+	b, err := p.MarshalCompact()
+	if err != nil {
+		t.Fatalf("cannot marshal %v: %v", p, err)
+	}
+	if b != [5]byte{10, 1, 0, 0, 16} {
+		t.Errorf("invalid compact encoding: actual=%v want=[10 1 0 0 16]", b)
+	}
+	var q MaskedIPv4Addr
+	if err := q.UnmarshalCompact(b); err != nil || q != p.Canonicalize() {
+		t.Errorf("invalid round-trip: actual=%v want=%v err=%v", q,
+			p.Canonicalize(), err)
+	}
+
+	p.Mask = IPv4Addr{255, 0, 255, 0}
+	if _, err := p.MarshalCompact(); err == nil {
+		t.Errorf("no error for a non-contiguous mask")
+	}
+	if err := q.UnmarshalCompact([5]byte{4: 33}); err == nil {
+		t.Errorf("no error for an invalid prefix length")
+	}
+}
+
+func TestMaskedIPv6Compact(t *testing.T) {
+	p := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 1},
+		Mask: MaskFromPrefixLen6(32),
+	}
+	b, err := p.MarshalCompact()
+	if err != nil {
+		t.Fatalf("cannot marshal %v: %v", p, err)
+	}
+	var q MaskedIPv6Addr
+	if err := q.UnmarshalCompact(b); err != nil || q != p.Canonicalize() {
+		t.Errorf("invalid round-trip: actual=%v want=%v err=%v", q,
+			p.Canonicalize(), err)
+	}
+
+	p.Mask = IPv6Addr{0xFF, 0x00, 0xFF}
+	if _, err := p.MarshalCompact(); err == nil {
+		t.Errorf("no error for a non-contiguous mask")
+	}
+}
+
+func TestFromBytes(t *testing.T) {
+	b := []byte{10, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	if ip, err := IPv4FromBytes(b[:4]); err != nil ||
+		ip != (IPv4Addr{10, 1, 2, 3}) {
+		t.Errorf("invalid IPv4 address: actual=%v err=%v", ip, err)
+	}
+	if mac, err := MACFromBytes(b[:6]); err != nil ||
+		mac != (MACAddr{10, 1, 2, 3, 4, 5}) {
+		t.Errorf("invalid MAC address: actual=%v err=%v", mac, err)
+	}
+	if ip, err := IPv6FromBytes(b[:16]); err != nil || ip[15] != 15 {
+		t.Errorf("invalid IPv6 address: actual=%v err=%v", ip, err)
+	}
+
+	for _, l := range []int{0, 3, 5} {
+		if _, err := IPv4FromBytes(b[:l]); err == nil {
+			t.Errorf("no error for a %d-byte IPv4 address", l)
+		}
+	}
+	for _, l := range []int{5, 7} {
+		if _, err := MACFromBytes(b[:l]); err == nil {
+			t.Errorf("no error for a %d-byte MAC address", l)
+		}
+	}
+	for _, l := range []int{4, 15, 17} {
+		if _, err := IPv6FromBytes(b[:l]); err == nil {
+			t.Errorf("no error for a %d-byte IPv6 address", l)
+		}
+	}
+
+	_, err := IPv4FromBytes(b[:6])
+	if want := "IPv4 address needs 4 bytes, got 6"; err.Error() != want {
+		t.Errorf("invalid error: actual=%q want=%q", err, want)
+	}
+}
+
+func TestIPv4Int64(t *testing.T) {
+	for _, ip := range []IPv4Addr{{}, {10, 0, 0, 1}, {255, 255, 255, 255}} {
+		n := ip.ToInt64()
+		if n < 0 {
+			t.Errorf("negative integer for %v: %v", ip, n)
+		}
+		got, err := IPv4FromInt64(n)
+		if err != nil || got != ip {
+			t.Errorf("invalid round trip: actual=%v want=%v (%v)", got, ip, err)
+		}
+	}
+
+	for _, n := range []int64{-1, 1 << 32} {
+		if _, err := IPv4FromInt64(n); err == nil {
+			t.Errorf("converted out-of-range integer %v", n)
+		}
+	}
+}
+
+func TestIPv6Decimal(t *testing.T) {
+	tests := map[string]string{
+		"::":          "0",
+		"::1":         "1",
+		"2001:db8::1": "42540766411282592856903984951653826561",
+		"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff": "3402823669209384634633746" +
+			"07431768211455",
+	}
+	for s, d := range tests {
+		ip, _ := ParseIPv6(s)
+		if actual := ip.DecimalString(); actual != d {
+			t.Errorf("invalid decimal for %v: actual=%v want=%v", s, actual, d)
+		}
+		got, err := IPv6FromDecimal(d)
+		if err != nil || got != ip {
+			t.Errorf("invalid round trip: actual=%v want=%v (%v)", got, ip, err)
+		}
+		got, err = IPv6FromBytes(ip.ToBinary())
+		if err != nil || got != ip {
+			t.Errorf("invalid binary round trip: actual=%v want=%v (%v)", got, ip,
+				err)
+		}
+	}
+
+	for _, d := range []string{"-1", "340282366920938463463374607431768211456",
+		"0x1", ""} {
+		if _, err := IPv6FromDecimal(d); err == nil {
+			t.Errorf("converted invalid decimal %q", d)
+		}
+	}
+}
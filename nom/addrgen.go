@@ -0,0 +1,123 @@
+package nom
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+)
+
+// GenerateIPv4s returns the first count usable host addresses in base. The
+// network and broadcast addresses are skipped, except for /31 and /32
+// prefixes where every address is usable. It returns an error if the prefix
+// has fewer than count usable hosts.
+//
+// This is mostly useful for building emulated topologies in tests and demos.
+func GenerateIPv4s(base MaskedIPv4Addr, count int) ([]IPv4Addr, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("invalid number of addresses: %d", count)
+	}
+
+	hostBits := uint(32 - base.Mask.AsCIDRMask())
+	size := uint64(1) << hostBits
+	first := base.Addr.Mask(base.Mask)
+	if hostBits > 1 {
+		size -= 2
+		first = first.Next()
+	}
+	if uint64(count) > size {
+		return nil, fmt.Errorf("%v has only %d usable hosts", base, size)
+	}
+
+	ips := make([]IPv4Addr, 0, count)
+	for ip := first; len(ips) < count; ip = ip.Next() {
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// GenerateMACs returns count consecutive MAC addresses starting from base.
+// Every generated address is a unicast address with its locally-administered
+// bit set, so that they never collide with vendor-assigned addresses: base is
+// adjusted accordingly before counting, and only its last 5 octets are
+// incremented. It returns an error if count is negative or if the addresses
+// would carry into the first octet.
+func GenerateMACs(base MACAddr, count int) ([]MACAddr, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("invalid number of addresses: %d", count)
+	}
+
+	base.SetLocallyAdministered(true)
+	base.SetMulticast(false)
+	if avail := 1<<40 - base.Uint64()&(1<<40-1); uint64(count) > avail {
+		return nil, fmt.Errorf("only %d addresses follow %v", avail, base)
+	}
+
+	macs := make([]MACAddr, 0, count)
+	for mac := base; len(macs) < count; mac = mac.Next() {
+		macs = append(macs, mac)
+	}
+	return macs, nil
+}
+
+// randomAddrRetries is the number of random addresses that RandomIPv4In,
+// RandomIPv6In, and RandomMACIn try before giving up.
+const randomAddrRetries = 128
+
+// RandomIPv4In returns a random usable host address in prefix that exclude
+// does not reject. Usable hosts are the same as in Hosts. exclude can be nil.
+// It returns an error if it cannot find such an address after a bounded
+// number of attempts, which is likely when the prefix is almost saturated.
+//
+// This is mostly useful in tests that need a free address in a subnet.
+func RandomIPv4In(r *rand.Rand, prefix MaskedIPv4Addr,
+	exclude func(IPv4Addr) bool) (IPv4Addr, error) {
+
+	first, last := prefix.hostRange()
+	n := int64(last-first) + 1
+	var ip IPv4Addr
+	for i := 0; i < randomAddrRetries; i++ {
+		ip.FromUint(first + uint32(r.Int63n(n)))
+		if exclude == nil || !exclude(ip) {
+			return ip, nil
+		}
+	}
+	return IPv4Addr{}, fmt.Errorf("no free address in %v after %d attempts",
+		prefix, randomAddrRetries)
+}
+
+// RandomIPv6In is the IPv6 variant of RandomIPv4In. Usable hosts are the same
+// as in MaskedIPv6Addr.Hosts.
+func RandomIPv6In(r *rand.Rand, prefix MaskedIPv6Addr,
+	exclude func(IPv6Addr) bool) (IPv6Addr, error) {
+
+	first, n := prefix.FirstHost(), prefix.NumHosts()
+	off := new(big.Int)
+	for i := 0; i < randomAddrRetries; i++ {
+		ip := first.Add(off.Rand(r, n))
+		if exclude == nil || !exclude(ip) {
+			return ip, nil
+		}
+	}
+	return IPv6Addr{}, fmt.Errorf("no free address in %v after %d attempts",
+		prefix, randomAddrRetries)
+}
+
+// RandomMACIn returns a random MAC address that matches prefix and that
+// exclude does not reject. Unlike the IP variants, every address is usable
+// and the mask need not be contiguous: the masked-out bits are random. exclude
+// can be nil.
+func RandomMACIn(r *rand.Rand, prefix MaskedMACAddr,
+	exclude func(MACAddr) bool) (MACAddr, error) {
+
+	fixed := prefix.Addr.Mask(prefix.Mask).Uint64()
+	host := ^prefix.Mask.Uint64() & (1<<48 - 1)
+	var mac MACAddr
+	for i := 0; i < randomAddrRetries; i++ {
+		mac.FromUint64(fixed | uint64(r.Int63())&host)
+		if exclude == nil || !exclude(mac) {
+			return mac, nil
+		}
+	}
+	return MACAddr{}, fmt.Errorf("no free address in %v after %d attempts",
+		prefix, randomAddrRetries)
+}
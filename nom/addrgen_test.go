@@ -0,0 +1,154 @@
+package nom
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGenerateIPv4s(t *testing.T) {
+	base := CIDRToMaskedIPv4(0x0A000000, 30)
+	ips, err := GenerateIPv4s(base, 2)
+	if err != nil {
+		t.Fatalf("cannot generate addresses: %v", err)
+	}
+	want := []IPv4Addr{{10, 0, 0, 1}, {10, 0, 0, 2}}
+	for i := range want {
+		if ips[i] != want[i] {
+			t.Errorf("invalid generated address: actual=%v want=%v", ips[i], want[i])
+		}
+	}
+
+	if _, err := GenerateIPv4s(base, 3); err == nil {
+		t.Errorf("generated more addresses than %v can hold", base)
+	}
+
+	p2p := CIDRToMaskedIPv4(0x0A000000, 31)
+	ips, err = GenerateIPv4s(p2p, 2)
+	if err != nil {
+		t.Fatalf("cannot generate addresses: %v", err)
+	}
+	if ips[0] != (IPv4Addr{10, 0, 0, 0}) || ips[1] != (IPv4Addr{10, 0, 0, 1}) {
+		t.Errorf("invalid generated addresses for %v: %v", p2p, ips)
+	}
+}
+
+func TestGenerateMACs(t *testing.T) {
+	macs, err := GenerateMACs(MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0xFF}, 2)
+	if err != nil {
+		t.Fatalf("cannot generate macs: %v", err)
+	}
+	want := []MACAddr{
+		{0x02, 0x11, 0x22, 0x33, 0x44, 0xFF},
+		{0x02, 0x11, 0x22, 0x33, 0x45, 0x00},
+	}
+	for i := range want {
+		if macs[i] != want[i] {
+			t.Errorf("invalid generated mac: actual=%v want=%v", macs[i], want[i])
+		}
+	}
+
+	if _, err := GenerateMACs(MACAddr{}, -1); err == nil {
+		t.Errorf("generated a negative number of macs")
+	}
+	tests := []struct {
+		base  MACAddr
+		count int
+		last  MACAddr
+		ok    bool
+	}{
+		// Would reach the broadcast address and then wrap around.
+		{MACAddr{0xfd, 0xff, 0xff, 0xff, 0xff, 0xff}, 3, MACAddr{}, false},
+		{MACAddr{0xfd, 0xff, 0xff, 0xff, 0xff, 0xff}, 1,
+			MACAddr{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff}, true},
+		// Would carry into the first octet and set the multicast bit.
+		{MACAddr{0x00, 0xff, 0xff, 0xff, 0xff, 0xfe}, 3, MACAddr{}, false},
+		{MACAddr{0x00, 0xff, 0xff, 0xff, 0xff, 0xfe}, 2,
+			MACAddr{0x02, 0xff, 0xff, 0xff, 0xff, 0xff}, true},
+	}
+	for _, tc := range tests {
+		macs, err := GenerateMACs(tc.base, tc.count)
+		if (err == nil) != tc.ok {
+			t.Errorf("invalid error for %d macs from %v: %v", tc.count, tc.base,
+				err)
+			continue
+		}
+		if !tc.ok {
+			continue
+		}
+		for _, mac := range macs {
+			if mac.IsGroup() || !mac.IsLocallyAdministered() {
+				t.Errorf("invalid generated mac from %v: %v", tc.base, mac)
+			}
+		}
+		if l := macs[len(macs)-1]; l != tc.last {
+			t.Errorf("invalid last mac from %v: actual=%v want=%v", tc.base, l,
+				tc.last)
+		}
+	}
+}
+
+func TestRandomIPv4In(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	prefix := CIDRToMaskedIPv4(0x0A000000, 30)
+	used := map[IPv4Addr]bool{{10, 0, 0, 1}: true}
+	ip, err := RandomIPv4In(r, prefix, func(ip IPv4Addr) bool {
+		return used[ip]
+	})
+	if err != nil {
+		t.Fatalf("cannot generate address: %v", err)
+	}
+	if ip != (IPv4Addr{10, 0, 0, 2}) {
+		t.Errorf("invalid random address: actual=%v want=10.0.0.2", ip)
+	}
+
+	used[ip] = true
+	if _, err := RandomIPv4In(r, prefix, func(ip IPv4Addr) bool {
+		return used[ip]
+	}); err == nil {
+		t.Errorf("generated an address in saturated %v", prefix)
+	}
+}
+
+func TestRandomIPv6In(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	prefix, _ := ParseCIDRv6("2001:db8::/64")
+	for i := 0; i < 100; i++ {
+		ip, err := RandomIPv6In(r, prefix, nil)
+		if err != nil {
+			t.Fatalf("cannot generate address: %v", err)
+		}
+		if !prefix.Match(ip) || ip == prefix.Addr {
+			t.Errorf("%v is not a usable host of %v", ip, prefix)
+		}
+	}
+
+	host, _ := ParseCIDRv6("2001:db8::1/128")
+	if _, err := RandomIPv6In(r, host, func(ip IPv6Addr) bool {
+		return true
+	}); err == nil {
+		t.Errorf("generated an address in saturated %v", host)
+	}
+}
+
+func TestRandomMACIn(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	prefix := MaskedMACAddr{
+		Addr: MACAddr{0x02, 0x11, 0x22, 0, 0, 0},
+		Mask: MACAddr{0xFF, 0xFF, 0xFF, 0, 0, 0},
+	}
+	for i := 0; i < 100; i++ {
+		mac, err := RandomMACIn(r, prefix, nil)
+		if err != nil {
+			t.Fatalf("cannot generate address: %v", err)
+		}
+		if !prefix.Match(mac) {
+			t.Errorf("%v does not match %v", mac, prefix)
+		}
+	}
+
+	if _, err := RandomMACIn(r, prefix, func(mac MACAddr) bool {
+		return true
+	}); err == nil {
+		t.Errorf("generated an address when every address is excluded")
+	}
+}
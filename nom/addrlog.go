@@ -0,0 +1,39 @@
+//go:build go1.21
+// +build go1.21
+
+package nom
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer, so that MAC addresses are logged in
+// their canonical form instead of as byte arrays.
+func (m MACAddr) LogValue() slog.Value {
+	return slog.StringValue(m.String())
+}
+
+// LogValue implements slog.LogValuer.
+func (mm MaskedMACAddr) LogValue() slog.Value {
+	return slog.StringValue(mm.String())
+}
+
+// LogValue implements slog.LogValuer, so that IP addresses are logged in their
+// canonical form instead of as byte arrays.
+func (ip IPv4Addr) LogValue() slog.Value {
+	return slog.StringValue(ip.String())
+}
+
+// LogValue implements slog.LogValuer.
+func (mi MaskedIPv4Addr) LogValue() slog.Value {
+	return slog.StringValue(mi.String())
+}
+
+// LogValue implements slog.LogValuer, so that IP addresses are logged in their
+// canonical form instead of as byte arrays.
+func (ip IPv6Addr) LogValue() slog.Value {
+	return slog.StringValue(ip.String())
+}
+
+// LogValue implements slog.LogValuer.
+func (mi MaskedIPv6Addr) LogValue() slog.Value {
+	return slog.StringValue(mi.String())
+}
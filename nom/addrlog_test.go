@@ -0,0 +1,42 @@
+//go:build go1.21
+// +build go1.21
+
+package nom
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestAddrLogValue(t *testing.T) {
+	addrs := map[slog.LogValuer]string{
+		MACAddr{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}: "01:02:03:04:05:06",
+		MaskedMACAddr{
+			Addr: MACAddr{0x01},
+			Mask: MACAddr{0xFF},
+		}: "01:00:00:00:00:00/ff:00:00:00:00:00",
+		IPv4Addr{127, 0, 0, 1}:                  "127.0.0.1",
+		CIDRToMaskedIPv4(0x0A000000, 8):         "10.0.0.0/8",
+		IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 1}: "2001:db8::1",
+		MaskedIPv6Addr{Mask: IPv6Addr{0xFF}}:    "::/8",
+	}
+	for a, s := range addrs {
+		var buf bytes.Buffer
+		log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == slog.LevelKey ||
+					a.Key == slog.MessageKey {
+
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		log.Info("", "addr", a)
+		if want := "addr=" + s + "\n"; buf.String() != want {
+			t.Errorf("invalid logged address: actual=%q want=%q", buf.String(),
+				want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package nom
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseIPv4 parses an IPv4 address in dotted-decimal notation (e.g.,
+// "192.0.2.1").
+func ParseIPv4(s string) (IPv4Addr, error) {
+	var ip IPv4Addr
+	parsed := net.ParseIP(s)
+	if parsed == nil || strings.Contains(s, ":") {
+		return ip, fmt.Errorf("invalid IPv4 address %q", s)
+	}
+	copy(ip[:], parsed.To4())
+	return ip, nil
+}
+
+// ParseIPv6 parses an IPv6 address in any of the textual forms of RFC 4291
+// (e.g., "2001:db8::1" or "::ffff:192.0.2.1"). The address can be enclosed in
+// brackets as in URLs (e.g., "[2001:db8::1]").
+func ParseIPv6(s string) (IPv6Addr, error) {
+	var ip IPv6Addr
+	addr := s
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		addr = addr[1 : len(addr)-1]
+	}
+	parsed := net.ParseIP(addr)
+	if parsed == nil || !strings.Contains(addr, ":") {
+		return ip, fmt.Errorf("invalid IPv6 address %q", s)
+	}
+	copy(ip[:], parsed.To16())
+	return ip, nil
+}
+
+// ParseIPv6HostPort parses an IPv6 address optionally followed by a port, as
+// found in URLs: "[2001:db8::1]:443", "[2001:db8::1]", or "2001:db8::1". The
+// port is 0 when not present. An address without brackets cannot have a
+// port, since the port would be ambiguous with the last group.
+func ParseIPv6HostPort(s string) (IPv6Addr, uint16, error) {
+	if !strings.HasPrefix(s, "[") {
+		ip, err := ParseIPv6(s)
+		return ip, 0, err
+	}
+
+	end := strings.Index(s, "]")
+	if end < 0 {
+		return IPv6Addr{}, 0, fmt.Errorf("missing ']' in %q", s)
+	}
+	ip, err := ParseIPv6(s[:end+1])
+	if err != nil {
+		return IPv6Addr{}, 0, err
+	}
+	rest := s[end+1:]
+	if rest == "" {
+		return ip, 0, nil
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return IPv6Addr{}, 0, fmt.Errorf("invalid port in %q", s)
+	}
+	port, err := strconv.ParseUint(rest[1:], 10, 16)
+	if err != nil {
+		return IPv6Addr{}, 0, fmt.Errorf("invalid port in %q", s)
+	}
+	return ip, uint16(port), nil
+}
+
+// ParseMAC parses a MAC address in any of the following forms:
+// "01:23:45:67:89:ab", "01-23-45-67-89-ab", or "0123.4567.89ab".
+func ParseMAC(s string) (MACAddr, error) {
+	var mac MACAddr
+	parsed, err := net.ParseMAC(s)
+	if err != nil || len(parsed) != len(mac) {
+		return mac, fmt.Errorf("invalid MAC address %q", s)
+	}
+	copy(mac[:], parsed)
+	return mac, nil
+}
+
+// ExtractMACs returns every valid MAC address found in text, in the order of
+// their first occurrence and without duplicates. Addresses can be in any of
+// the forms accepted by ParseMAC, and must be delimited by characters that
+// cannot be part of an address (eg, spaces, commas, or pipes). This is useful
+// to ingest the output of switch CLIs, such as "show mac address-table".
+func ExtractMACs(text string) []MACAddr {
+	var macs []MACAddr
+	seen := make(map[MACAddr]bool)
+	tokens := strings.FieldsFunc(text, func(r rune) bool {
+		return !isMACRune(r)
+	})
+	for _, tok := range tokens {
+		// Allow the punctuation of a sentence ending with an address.
+		tok = strings.TrimRight(tok, ".-:")
+		// Bare hex strings are more likely hashes or IDs than addresses.
+		if !strings.ContainsAny(tok, ".-:") {
+			continue
+		}
+		mac, err := ParseMAC(tok)
+		if err != nil || seen[mac] {
+			continue
+		}
+		seen[mac] = true
+		macs = append(macs, mac)
+	}
+	return macs
+}
+
+// isMACRune returns whether r can be part of a MAC address in any of the forms
+// accepted by ParseMAC.
+func isMACRune(r rune) bool {
+	switch {
+	case '0' <= r && r <= '9', 'a' <= r && r <= 'f', 'A' <= r && r <= 'F':
+		return true
+	case r == ':', r == '-', r == '.':
+		return true
+	}
+	return false
+}
+
+// ParseIPv4All parses each of lines as an IPv4 address, and collects all the
+// errors instead of stopping at the first one. Surrounding spaces are
+// ignored. The returned slices are parallel to lines: ips[i] is the address
+// in lines[i], or the zero address if errs[i] is not nil. errs is nil when
+// all lines are valid.
+func ParseIPv4All(lines []string) (ips []IPv4Addr, errs []error) {
+	ips = make([]IPv4Addr, len(lines))
+	for i, l := range lines {
+		var err error
+		if ips[i], err = ParseIPv4(strings.TrimSpace(l)); err != nil {
+			errs = setLineError(errs, len(lines), i, err)
+		}
+	}
+	return ips, errs
+}
+
+// ParseIPv6All is like ParseIPv4All but parses IPv6 addresses.
+func ParseIPv6All(lines []string) (ips []IPv6Addr, errs []error) {
+	ips = make([]IPv6Addr, len(lines))
+	for i, l := range lines {
+		var err error
+		if ips[i], err = ParseIPv6(strings.TrimSpace(l)); err != nil {
+			errs = setLineError(errs, len(lines), i, err)
+		}
+	}
+	return ips, errs
+}
+
+// ParseMACAll is like ParseIPv4All but parses MAC addresses.
+func ParseMACAll(lines []string) (macs []MACAddr, errs []error) {
+	macs = make([]MACAddr, len(lines))
+	for i, l := range lines {
+		var err error
+		if macs[i], err = ParseMAC(strings.TrimSpace(l)); err != nil {
+			errs = setLineError(errs, len(lines), i, err)
+		}
+	}
+	return macs, errs
+}
+
+// setLineError sets the error of line i, allocating the slice of errors
+// for n lines on the first error.
+func setLineError(errs []error, n, i int, err error) []error {
+	if errs == nil {
+		errs = make([]error, n)
+	}
+	errs[i] = fmt.Errorf("line %d: %v", i+1, err)
+	return errs
+}
+
+// CIDROption represents an option of ParseCIDRv4.
+type CIDROption func(o *cidrOptions)
+
+type cidrOptions struct {
+	allowNonContiguous bool
+}
+
+// AllowNonContiguous returns a CIDR option that accepts netmasks whose one
+// bits are not contiguous, as used in some ACLs.
+func AllowNonContiguous() CIDROption {
+	return func(o *cidrOptions) {
+		o.allowNonContiguous = true
+	}
+}
+
+// ParseCIDRv4 parses an IPv4 prefix in any of the following forms:
+// "10.0.0.0/8", "10.0.0.0/255.0.0.0", or "10.0.0.0 255.0.0.0". Non-contiguous
+// netmasks are rejected unless AllowNonContiguous is passed. Host bits are
+// preserved in the returned address; use Canonicalize to clear them.
+func ParseCIDRv4(s string, opts ...CIDROption) (MaskedIPv4Addr, error) {
+	var o cidrOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var mi MaskedIPv4Addr
+	i := strings.IndexAny(s, "/ ")
+	if i < 0 {
+		return mi, fmt.Errorf("invalid IPv4 prefix %q", s)
+	}
+
+	var err error
+	if mi.Addr, err = ParseIPv4(s[:i]); err != nil {
+		return mi, err
+	}
+
+	m := strings.TrimLeft(s[i+1:], " ")
+	if s[i] == ' ' || strings.Contains(m, ".") {
+		if mi.Mask, err = ParseIPv4(m); err != nil {
+			return mi, fmt.Errorf("invalid IPv4 netmask in %q", s)
+		}
+		if !o.allowNonContiguous && !IsValidNetmask4(mi.Mask) {
+			return mi, fmt.Errorf("non-contiguous IPv4 netmask in %q", s)
+		}
+		return mi, nil
+	}
+
+	l, err := strconv.ParseUint(m, 10, 8)
+	if err != nil || l > 32 {
+		return mi, fmt.Errorf("invalid IPv4 prefix length in %q", s)
+	}
+	mi.Mask = MaskFromPrefixLen4(int(l))
+	return mi, nil
+}
+
+// ParseCIDRv6 parses an IPv6 prefix in CIDR notation, e.g. "2001:db8::/32".
+// Host bits are preserved in the returned address.
+func ParseCIDRv6(s string) (MaskedIPv6Addr, error) {
+	var mi MaskedIPv6Addr
+	i := strings.Index(s, "/")
+	if i < 0 {
+		return mi, fmt.Errorf("invalid IPv6 prefix %q", s)
+	}
+
+	var err error
+	if mi.Addr, err = ParseIPv6(s[:i]); err != nil {
+		return mi, err
+	}
+	l, err := strconv.ParseUint(s[i+1:], 10, 8)
+	if err != nil || l > 128 {
+		return mi, fmt.Errorf("invalid IPv6 prefix length in %q", s)
+	}
+	mi.Mask = MaskFromPrefixLen6(int(l))
+	return mi, nil
+}
+
+// PrefixLenToNetmask4 returns the dotted-decimal netmask of a prefix of
+// length n; eg, "255.255.255.0" for 24.
+func PrefixLenToNetmask4(n int) (string, error) {
+	if n < 0 || n > 32 {
+		return "", fmt.Errorf("invalid IPv4 prefix length %d", n)
+	}
+	return MaskFromPrefixLen4(n).String(), nil
+}
+
+// NetmaskToPrefixLen4 returns the prefix length of a dotted-decimal netmask;
+// eg, 24 for "255.255.255.0". It returns an error for non-contiguous masks.
+func NetmaskToPrefixLen4(s string) (int, error) {
+	mask, err := ParseIPv4(s)
+	if err != nil {
+		return 0, err
+	}
+	if !IsValidNetmask4(mask) {
+		return 0, fmt.Errorf("non-contiguous IPv4 netmask %q", s)
+	}
+	return mask.AsCIDRMask(), nil
+}
+
+// PrefixLenToNetmask6 returns the netmask of an IPv6 prefix of length n in
+// hexadecimal IPv6 notation; eg, "ffff:ffff:ffff:ffff::" for 64.
+func PrefixLenToNetmask6(n int) (string, error) {
+	if n < 0 || n > 128 {
+		return "", fmt.Errorf("invalid IPv6 prefix length %d", n)
+	}
+	return MaskFromPrefixLen6(n).String(), nil
+}
+
+// NetmaskToPrefixLen6 returns the prefix length of an IPv6 netmask in
+// hexadecimal IPv6 notation. It returns an error for non-contiguous masks.
+func NetmaskToPrefixLen6(s string) (int, error) {
+	mask, err := ParseIPv6(s)
+	if err != nil {
+		return 0, err
+	}
+	if !IsValidNetmask6(mask) {
+		return 0, fmt.Errorf("non-contiguous IPv6 netmask %q", s)
+	}
+	return mask.AsCIDRMask(), nil
+}
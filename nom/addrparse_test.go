@@ -0,0 +1,229 @@
+package nom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCIDR(t *testing.T) {
+	p, err := ParseCIDRv4("10.1.2.3/16")
+	want := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 1, 2, 3},
+		Mask: IPv4Addr{255, 255, 0, 0},
+	}
+	if err != nil || p != want {
+		t.Errorf("invalid prefix: actual=%v want=%v err=%v", p, want, err)
+	}
+	for _, s := range []string{"10.0.0.0", "10.0.0.0/33", "10.0.0/8", "::/0"} {
+		if _, err := ParseCIDRv4(s); err == nil {
+			t.Errorf("no error for invalid prefix %q", s)
+		}
+	}
+
+	p6, err := ParseCIDRv6("2001:db8::/32")
+	if err != nil || p6.String() != "2001:db8::/32" {
+		t.Errorf("invalid prefix: actual=%v want=2001:db8::/32 err=%v", p6, err)
+	}
+	if _, err := ParseCIDRv6("2001:db8::/129"); err == nil {
+		t.Errorf("no error for an invalid prefix length")
+	}
+}
+
+func TestParseCIDRNetmask(t *testing.T) {
+	want := CIDRToMaskedIPv4(0x0A000000, 24)
+	for _, s := range []string{
+		"10.0.0.0/24",
+		"10.0.0.0/255.255.255.0",
+		"10.0.0.0 255.255.255.0",
+		"10.0.0.0   255.255.255.0",
+	} {
+		p, err := ParseCIDRv4(s)
+		if err != nil || p != want {
+			t.Errorf("invalid prefix for %q: actual=%v want=%v err=%v", s, p, want,
+				err)
+		}
+	}
+
+	for _, s := range []string{
+		"10.0.0.0/255.0.255.0",
+		"10.0.0.0 255.255.255",
+		"10.0.0.0 24",
+	} {
+		if _, err := ParseCIDRv4(s); err == nil {
+			t.Errorf("no error for invalid prefix %q", s)
+		}
+	}
+
+	p, err := ParseCIDRv4("10.0.0.0 255.0.255.0", AllowNonContiguous())
+	want = MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 0, 0, 0},
+		Mask: IPv4Addr{255, 0, 255, 0},
+	}
+	if err != nil || p != want {
+		t.Errorf("invalid non-contiguous prefix: actual=%v want=%v err=%v", p,
+			want, err)
+	}
+}
+
+func TestDefaultRoute(t *testing.T) {
+	p, err := ParseCIDRv4("0.0.0.0/0")
+	if err != nil || p != DefaultRouteIPv4 {
+		t.Errorf("invalid default route: actual=%v want=%v", DefaultRouteIPv4, p)
+	}
+	if !DefaultRouteIPv4.IsDefaultRoute() {
+		t.Errorf("%v should be a default route", DefaultRouteIPv4)
+	}
+	if CIDRToMaskedIPv4(0x0A000000, 8).IsDefaultRoute() {
+		t.Errorf("10.0.0.0/8 should not be a default route")
+	}
+
+	p6, err := ParseCIDRv6("::/0")
+	if err != nil || p6 != DefaultRouteIPv6 {
+		t.Errorf("invalid default route: actual=%v want=%v", DefaultRouteIPv6, p6)
+	}
+	if !DefaultRouteIPv6.IsDefaultRoute() {
+		t.Errorf("%v should be a default route", DefaultRouteIPv6)
+	}
+	if p6, _ = ParseCIDRv6("2001:db8::/32"); p6.IsDefaultRoute() {
+		t.Errorf("%v should not be a default route", p6)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	ips, errs := ParseIPv4All([]string{"10.0.0.1", " 10.0.0.2 ", "10.0.0",
+		"::1", "192.168.1.1"})
+	if len(ips) != 5 || len(errs) != 5 {
+		t.Fatalf("invalid result sizes: ips=%v errs=%v", len(ips), len(errs))
+	}
+	for i, bad := range []bool{false, false, true, true, false} {
+		if (errs[i] != nil) != bad {
+			t.Errorf("invalid error for line %d: %v", i+1, errs[i])
+		}
+	}
+	if ips[1] != (IPv4Addr{10, 0, 0, 2}) || ips[4] != (IPv4Addr{192, 168, 1, 1}) {
+		t.Errorf("invalid parsed addresses: %v", ips)
+	}
+	if want := `line 3: invalid IPv4 address "10.0.0"`; errs[2].Error() != want {
+		t.Errorf("invalid error: actual=%q want=%q", errs[2], want)
+	}
+
+	if _, errs := ParseIPv4All([]string{"10.0.0.1"}); errs != nil {
+		t.Errorf("errors for valid lines: %v", errs)
+	}
+
+	ip6s, errs := ParseIPv6All([]string{"2001:db8::1", "10.0.0.1"})
+	if errs[0] != nil || errs[1] == nil || ip6s[0].String() != "2001:db8::1" {
+		t.Errorf("invalid IPv6 parse: ips=%v errs=%v", ip6s, errs)
+	}
+
+	macs, errs := ParseMACAll([]string{"zz:00:00:00:00:00", "00:11:22:33:44:55"})
+	if errs[0] == nil || errs[1] != nil ||
+		macs[1] != (MACAddr{0, 0x11, 0x22, 0x33, 0x44, 0x55}) {
+		t.Errorf("invalid MAC parse: macs=%v errs=%v", macs, errs)
+	}
+}
+
+func TestNetmask4(t *testing.T) {
+	masks := []string{
+		"0.0.0.0", "128.0.0.0", "192.0.0.0", "224.0.0.0", "240.0.0.0",
+		"248.0.0.0", "252.0.0.0", "254.0.0.0", "255.0.0.0", "255.128.0.0",
+		"255.192.0.0", "255.224.0.0", "255.240.0.0", "255.248.0.0",
+		"255.252.0.0", "255.254.0.0", "255.255.0.0", "255.255.128.0",
+		"255.255.192.0", "255.255.224.0", "255.255.240.0", "255.255.248.0",
+		"255.255.252.0", "255.255.254.0", "255.255.255.0", "255.255.255.128",
+		"255.255.255.192", "255.255.255.224", "255.255.255.240",
+		"255.255.255.248", "255.255.255.252", "255.255.255.254",
+		"255.255.255.255",
+	}
+	for l, m := range masks {
+		if s, err := PrefixLenToNetmask4(l); err != nil || s != m {
+			t.Errorf("invalid netmask for /%d: actual=%v want=%v err=%v", l, s, m,
+				err)
+		}
+		if n, err := NetmaskToPrefixLen4(m); err != nil || n != l {
+			t.Errorf("invalid prefix length for %v: actual=%v want=%v err=%v", m,
+				n, l, err)
+		}
+	}
+
+	for _, l := range []int{-1, 33} {
+		if _, err := PrefixLenToNetmask4(l); err == nil {
+			t.Errorf("no error for prefix length %d", l)
+		}
+	}
+	for _, m := range []string{"255.0.255.0", "0.0.0.255", "255.255.255"} {
+		if _, err := NetmaskToPrefixLen4(m); err == nil {
+			t.Errorf("no error for netmask %q", m)
+		}
+	}
+}
+
+func TestNetmask6(t *testing.T) {
+	if s, err := PrefixLenToNetmask6(64); err != nil ||
+		s != "ffff:ffff:ffff:ffff::" {
+		t.Errorf("invalid netmask for /64: actual=%v err=%v", s, err)
+	}
+	if n, err := NetmaskToPrefixLen6("ffff:ffff:fff0::"); err != nil || n != 44 {
+		t.Errorf("invalid prefix length for ffff:ffff:fff0::: %v err=%v", n, err)
+	}
+	if _, err := NetmaskToPrefixLen6("ffff::ffff"); err == nil {
+		t.Errorf("no error for a non-contiguous netmask")
+	}
+	if _, err := PrefixLenToNetmask6(129); err == nil {
+		t.Errorf("no error for prefix length 129")
+	}
+}
+
+func TestParseIPv6HostPort(t *testing.T) {
+	want, _ := ParseIPv6("2001:db8::1")
+	tests := []struct {
+		s    string
+		port uint16
+	}{
+		{"2001:db8::1", 0},
+		{"[2001:db8::1]", 0},
+		{"[2001:db8::1]:443", 443},
+	}
+	for _, tc := range tests {
+		ip, port, err := ParseIPv6HostPort(tc.s)
+		if err != nil {
+			t.Errorf("cannot parse %q: %v", tc.s, err)
+			continue
+		}
+		if ip != want || port != tc.port {
+			t.Errorf("invalid host and port for %q: actual=%v,%v want=%v,%v",
+				tc.s, ip, port, want, tc.port)
+		}
+	}
+
+	if ip, err := ParseIPv6("[2001:db8::1]"); err != nil || ip != want {
+		t.Errorf("cannot parse bracketed address: %v %v", ip, err)
+	}
+
+	for _, s := range []string{"[2001:db8::1", "[2001:db8::1]443",
+		"[2001:db8::1]:", "[2001:db8::1]:65536", "[10.0.0.1]:80"} {
+		if _, _, err := ParseIPv6HostPort(s); err == nil {
+			t.Errorf("parsed invalid host and port %q", s)
+		}
+	}
+}
+
+func TestExtractMACs(t *testing.T) {
+	text := `Vlan    Mac Address       Type        Ports
+----    -----------       --------    -----
+ 100    0011.2233.4455    DYNAMIC     Gi1/0/1
+ 100    00:11:22:33:44:55 DYNAMIC     Gi1/0/2
+ 200    aa-bb-cc-dd-ee-ff STATIC      Gi1/0/3
+ 200    0xdeadbeef 2001:db8::1 10.0.0.1 deadbeefcafe
+ Total: 3. Last moved 02:00:00:00:00:01.
+ EUI-64 00:11:22:ff:fe:33:44:55`
+	macs := ExtractMACs(text)
+	want := []MACAddr{
+		{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+		{0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF},
+		{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+	}
+	if !reflect.DeepEqual(macs, want) {
+		t.Errorf("invalid extracted MACs: actual=%v want=%v", macs, want)
+	}
+}
@@ -0,0 +1,362 @@
+package nom
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IPv4Range is an inclusive range of IPv4 addresses.
+type IPv4Range struct {
+	Low  IPv4Addr
+	High IPv4Addr
+}
+
+func (r IPv4Range) String() string {
+	return fmt.Sprintf("%v-%v", r.Low, r.High)
+}
+
+// CIDRs returns the minimal list of prefixes that exactly cover the range,
+// sorted by address.
+func (r IPv4Range) CIDRs() []MaskedIPv4Addr {
+	var prefixes []MaskedIPv4Addr
+	lo, hi := uint64(r.Low.Uint32()), uint64(r.High.Uint32())
+	for lo <= hi {
+		size := uint64(1) << 32
+		if lo != 0 {
+			size = lo & -lo
+		}
+		for lo+size-1 > hi {
+			size >>= 1
+		}
+		l := uint(32)
+		for s := size; s > 1; s >>= 1 {
+			l--
+		}
+		prefixes = append(prefixes, CIDRToMaskedIPv4(uint32(lo), l))
+		lo += size
+	}
+	return prefixes
+}
+
+// CIDRsLimited returns at most maxEntries prefixes that cover the range. If
+// the range cannot be covered exactly with maxEntries prefixes, the result
+// over-approximates the range (ie, it also covers addresses outside of the
+// range) and the returned boolean is true. The over-approximation greedily
+// merges neighboring prefixes to add as few extra addresses as possible. This
+// is useful when programming hardware with a limited number of TCAM entries.
+// If maxEntries is not positive, no prefix fits and it returns nil and true.
+func (r IPv4Range) CIDRsLimited(maxEntries int) ([]MaskedIPv4Addr, bool) {
+	if maxEntries <= 0 {
+		return nil, true
+	}
+	prefixes := r.CIDRs()
+	if len(prefixes) <= maxEntries {
+		return prefixes, false
+	}
+
+	for len(prefixes) > maxEntries {
+		var best MaskedIPv4Addr
+		var bestFrom, bestTo int
+		bestCost := uint64(1<<64 - 1)
+		for i := 0; i < len(prefixes)-1; i++ {
+			c := prefixes[i].CommonPrefix(prefixes[i+1])
+			cost := uint64(1) << uint(32-c.PrefixLen())
+			from, to := i, i+1
+			for from > 0 && c.Subsumes(prefixes[from-1]) {
+				from--
+			}
+			for to < len(prefixes)-1 && c.Subsumes(prefixes[to+1]) {
+				to++
+			}
+			for _, p := range prefixes[from : to+1] {
+				cost -= uint64(1) << uint(32-p.PrefixLen())
+			}
+			if cost < bestCost {
+				best, bestFrom, bestTo, bestCost = c, from, to, cost
+			}
+		}
+		merged := append(prefixes[:bestFrom:bestFrom], best)
+		prefixes = append(merged, prefixes[bestTo+1:]...)
+	}
+	return prefixes, true
+}
+
+// ParseIPv4Range parses an IPv4 range in the "low-high" notation, e.g.
+// "10.0.0.100-10.0.0.200". The high address can be abbreviated to its last
+// octet, e.g. "10.0.0.100-200". It returns an error if high is less than low.
+func ParseIPv4Range(s string) (IPv4Range, error) {
+	var r IPv4Range
+	i := strings.Index(s, "-")
+	if i < 0 {
+		return r, fmt.Errorf("invalid IPv4 range %q", s)
+	}
+
+	var err error
+	if r.Low, err = ParseIPv4(s[:i]); err != nil {
+		return r, err
+	}
+	if high := s[i+1:]; strings.Contains(high, ".") {
+		if r.High, err = ParseIPv4(high); err != nil {
+			return r, err
+		}
+	} else {
+		octet, err := strconv.ParseUint(high, 10, 8)
+		if err != nil {
+			return r, fmt.Errorf("invalid IPv4 range %q", s)
+		}
+		r.High = r.Low
+		r.High[3] = byte(octet)
+	}
+
+	if r.High.Less(r.Low) {
+		return r, fmt.Errorf("invalid IPv4 range %q: high is less than low", s)
+	}
+	return r, nil
+}
+
+// IPv6Range is an inclusive range of IPv6 addresses.
+type IPv6Range struct {
+	Low  IPv6Addr
+	High IPv6Addr
+}
+
+func (r IPv6Range) String() string {
+	return fmt.Sprintf("%v-%v", r.Low, r.High)
+}
+
+// ParseIPv6Range parses an IPv6 range in the "low-high" notation, e.g.
+// "2001:db8::1-2001:db8::ff". It returns an error if high is less than low.
+func ParseIPv6Range(s string) (IPv6Range, error) {
+	var r IPv6Range
+	i := strings.Index(s, "-")
+	if i < 0 {
+		return r, fmt.Errorf("invalid IPv6 range %q", s)
+	}
+
+	var err error
+	if r.Low, err = ParseIPv6(s[:i]); err != nil {
+		return r, err
+	}
+	if r.High, err = ParseIPv6(s[i+1:]); err != nil {
+		return r, err
+	}
+
+	if r.High.Less(r.Low) {
+		return r, fmt.Errorf("invalid IPv6 range %q: high is less than low", s)
+	}
+	return r, nil
+}
+
+// MACRange is an inclusive range of MAC addresses.
+type MACRange struct {
+	Low  MACAddr
+	High MACAddr
+}
+
+func (r MACRange) String() string {
+	return fmt.Sprintf("%v-%v", r.Low, r.High)
+}
+
+// ParseMACRange parses a MAC range in the "low-high" notation, e.g.
+// "00:00:5e:00:01:00-00:00:5e:00:01:ff". Since hyphens are also valid MAC
+// separators, the range separator is the hyphen that splits s into two valid
+// MAC addresses. It returns an error if high is less than low.
+func ParseMACRange(s string) (MACRange, error) {
+	var r MACRange
+	for i := range s {
+		if s[i] != '-' {
+			continue
+		}
+
+		low, err := ParseMAC(s[:i])
+		if err != nil {
+			continue
+		}
+		high, err := ParseMAC(s[i+1:])
+		if err != nil {
+			continue
+		}
+
+		if high.Less(low) {
+			return r, fmt.Errorf("invalid MAC range %q: high is less than low", s)
+		}
+		return MACRange{Low: low, High: high}, nil
+	}
+	return r, fmt.Errorf("invalid MAC range %q", s)
+}
+
+type ipv4RangesByLow []IPv4Range
+
+func (s ipv4RangesByLow) Len() int           { return len(s) }
+func (s ipv4RangesByLow) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s ipv4RangesByLow) Less(i, j int) bool { return s[i].Low.Less(s[j].Low) }
+
+// MergeIPv4Ranges coalesces overlapping and adjacent ranges into the minimal
+// set of disjoint ranges, sorted by Low. For example, it merges
+// 10.0.0.1-10.0.0.9, 10.0.0.5-10.0.0.20, and 10.0.0.21-10.0.0.30 into
+// 10.0.0.1-10.0.0.30. This is useful to compute the total space of leases
+// granted individually. ranges is not modified.
+func MergeIPv4Ranges(ranges []IPv4Range) []IPv4Range {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := append([]IPv4Range(nil), ranges...)
+	sort.Sort(ipv4RangesByLow(sorted))
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if last.High.Less(r.Low) && last.High.Next() != r.Low {
+			merged = append(merged, r)
+			continue
+		}
+		if last.High.Less(r.High) {
+			last.High = r.High
+		}
+	}
+	return merged
+}
+
+// indexedIPv4Range is a range along with its index in a list.
+type indexedIPv4Range struct {
+	IPv4Range
+	index int
+}
+
+type indexedIPv4Ranges []indexedIPv4Range
+
+func (s indexedIPv4Ranges) Len() int      { return len(s) }
+func (s indexedIPv4Ranges) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s indexedIPv4Ranges) Less(i, j int) bool {
+	return s[i].Low.Less(s[j].Low)
+}
+
+// ValidateIPv4Pools checks that no two pools (eg, DHCP pools) overlap, which
+// would cause an address to be allocated twice. The returned error lists
+// every overlapping pair it finds, eg, "overlapping pools #0
+// (10.0.0.1-10.0.0.50) and #2 (10.0.0.40-10.0.0.60)", as well as pools whose
+// high address is less than their low address. It returns nil if the pools
+// are valid.
+func ValidateIPv4Pools(pools []IPv4Range) error {
+	var problems []string
+	sorted := make([]indexedIPv4Range, 0, len(pools))
+	for i, p := range pools {
+		if p.High.Less(p.Low) {
+			problems = append(problems, fmt.Sprintf("invalid pool #%d (%v)", i, p))
+			continue
+		}
+		sorted = append(sorted, indexedIPv4Range{IPv4Range: p, index: i})
+	}
+	sort.Sort(indexedIPv4Ranges(sorted))
+
+	// Sweep the pools by their low address, keeping the pool that reaches the
+	// highest address so far. A pool overlaps a previous one iff it starts
+	// before that address.
+	for i, top := 1, 0; i < len(sorted); i++ {
+		p, t := sorted[i], sorted[top]
+		if !t.High.Less(p.Low) {
+			problems = append(problems, fmt.Sprintf("overlapping pools #%d (%v) "+
+				"and #%d (%v)", t.index, t.IPv4Range, p.index, p.IPv4Range))
+		}
+		if t.High.Less(p.High) {
+			top = i
+		}
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("%s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+type ipv6RangesByLow []IPv6Range
+
+func (s ipv6RangesByLow) Len() int           { return len(s) }
+func (s ipv6RangesByLow) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s ipv6RangesByLow) Less(i, j int) bool { return s[i].Low.Less(s[j].Low) }
+
+// MergeIPv6Ranges is the IPv6 variant of MergeIPv4Ranges.
+func MergeIPv6Ranges(ranges []IPv6Range) []IPv6Range {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := append([]IPv6Range(nil), ranges...)
+	sort.Sort(ipv6RangesByLow(sorted))
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if last.High.Less(r.Low) && last.High.Next() != r.Low {
+			merged = append(merged, r)
+			continue
+		}
+		if last.High.Less(r.High) {
+			last.High = r.High
+		}
+	}
+	return merged
+}
+
+type macRangesByLow []MACRange
+
+func (s macRangesByLow) Len() int           { return len(s) }
+func (s macRangesByLow) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s macRangesByLow) Less(i, j int) bool { return s[i].Low.Less(s[j].Low) }
+
+// MergeMACRanges is the MAC variant of MergeIPv4Ranges.
+func MergeMACRanges(ranges []MACRange) []MACRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := append([]MACRange(nil), ranges...)
+	sort.Sort(macRangesByLow(sorted))
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if last.High.Less(r.Low) && last.High.Next() != r.Low {
+			merged = append(merged, r)
+			continue
+		}
+		if last.High.Less(r.High) {
+			last.High = r.High
+		}
+	}
+	return merged
+}
+
+// IPv4Count returns the number of addresses in the inclusive range
+// [low, high]. It returns an error if high is less than low.
+func IPv4Count(low, high IPv4Addr) (uint64, error) {
+	if high.Less(low) {
+		return 0, fmt.Errorf("invalid range %v-%v", low, high)
+	}
+	return uint64(high.Uint32()-low.Uint32()) + 1, nil
+}
+
+// IPv6Count returns the number of addresses in the inclusive range
+// [low, high]. Since the full IPv6 space has 2^128 addresses, the result is a
+// big.Int. It returns an error if high is less than low.
+func IPv6Count(low, high IPv6Addr) (*big.Int, error) {
+	if high.Less(low) {
+		return nil, fmt.Errorf("invalid range %v-%v", low, high)
+	}
+	n := new(big.Int).SetBytes(high[:])
+	n.Sub(n, new(big.Int).SetBytes(low[:]))
+	return n.Add(n, big.NewInt(1)), nil
+}
+
+// MACCount returns the number of addresses in the inclusive range
+// [low, high]. It returns an error if high is less than low.
+func MACCount(low, high MACAddr) (uint64, error) {
+	if high.Less(low) {
+		return 0, fmt.Errorf("invalid range %v-%v", low, high)
+	}
+	return high.Uint64() - low.Uint64() + 1, nil
+}
+
+func init() {
+	gob.Register(IPv4Range{})
+	gob.Register(IPv6Range{})
+	gob.Register(MACRange{})
+}
@@ -0,0 +1,241 @@
+package nom
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIPv4Count(t *testing.T) {
+	n, err := IPv4Count(IPv4Addr{}, MaskNoneIPV4)
+	if err != nil || n != 1<<32 {
+		t.Errorf("invalid count for the whole space: actual=%v want=%v", n,
+			uint64(1<<32))
+	}
+	n, err = IPv4Count(IPv4Addr{10, 0, 0, 255}, IPv4Addr{10, 0, 1, 0})
+	if err != nil || n != 2 {
+		t.Errorf("invalid count: actual=%v want=%v", n, 2)
+	}
+	_, err = IPv4Count(IPv4Addr{10, 0, 0, 2}, IPv4Addr{10, 0, 0, 1})
+	if err == nil {
+		t.Errorf("no error for an inverted range")
+	}
+}
+
+func TestIPv6Count(t *testing.T) {
+	n, err := IPv6Count(IPv6Addr{}, MaskNoneIPV6)
+	want := new(big.Int).Lsh(big.NewInt(1), 128)
+	if err != nil || n.Cmp(want) != 0 {
+		t.Errorf("invalid count for the whole space: actual=%v want=%v", n, want)
+	}
+	n, err = IPv6Count(IPv6Addr{15: 1}, IPv6Addr{15: 1})
+	if err != nil || n.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("invalid count: actual=%v want=%v", n, 1)
+	}
+	if _, err = IPv6Count(IPv6Addr{15: 1}, IPv6Addr{}); err == nil {
+		t.Errorf("no error for an inverted range")
+	}
+}
+
+func TestMACCount(t *testing.T) {
+	n, err := MACCount(MACAddr{}, BroadcastMAC)
+	if err != nil || n != 1<<48 {
+		t.Errorf("invalid count for the whole space: actual=%v want=%v", n,
+			uint64(1<<48))
+	}
+	if _, err = MACCount(BroadcastMAC, MACAddr{}); err == nil {
+		t.Errorf("no error for an inverted range")
+	}
+}
+
+func TestParseIPv4Range(t *testing.T) {
+	ranges := map[string]IPv4Range{
+		"10.0.0.100-10.0.0.200": {IPv4Addr{10, 0, 0, 100}, IPv4Addr{10, 0, 0, 200}},
+		"10.0.0.100-200":        {IPv4Addr{10, 0, 0, 100}, IPv4Addr{10, 0, 0, 200}},
+		"10.0.0.255-10.0.1.0":   {IPv4Addr{10, 0, 0, 255}, IPv4Addr{10, 0, 1, 0}},
+	}
+	for s, want := range ranges {
+		r, err := ParseIPv4Range(s)
+		if err != nil || r != want {
+			t.Errorf("invalid range for %q: actual=%v want=%v err=%v", s, r, want,
+				err)
+		}
+	}
+
+	for _, s := range []string{"10.0.0.1", "10.0.0.200-100", "10.0.0.1-256",
+		"10.0.0.1-x"} {
+		if _, err := ParseIPv4Range(s); err == nil {
+			t.Errorf("no error for invalid range %q", s)
+		}
+	}
+}
+
+func TestParseIPv6Range(t *testing.T) {
+	r, err := ParseIPv6Range("2001:db8::1-2001:db8::ff")
+	want := IPv6Range{
+		Low:  IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 0x01},
+		High: IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 15: 0xFF},
+	}
+	if err != nil || r != want {
+		t.Errorf("invalid ipv6 range: actual=%v want=%v err=%v", r, want, err)
+	}
+	if _, err := ParseIPv6Range("2001:db8::ff-2001:db8::1"); err == nil {
+		t.Errorf("no error for an inverted range")
+	}
+}
+
+func TestParseMACRange(t *testing.T) {
+	want := MACRange{
+		Low:  MACAddr{0x00, 0x00, 0x5e, 0x00, 0x01, 0x00},
+		High: MACAddr{0x00, 0x00, 0x5e, 0x00, 0x01, 0xFF},
+	}
+	for _, s := range []string{
+		"00:00:5e:00:01:00-00:00:5e:00:01:ff",
+		"00-00-5e-00-01-00-00-00-5e-00-01-ff",
+		"0000.5e00.0100-0000.5e00.01ff",
+	} {
+		r, err := ParseMACRange(s)
+		if err != nil || r != want {
+			t.Errorf("invalid range for %q: actual=%v want=%v err=%v", s, r, want,
+				err)
+		}
+	}
+	if _, err := ParseMACRange("00:00:5e:00:01:00"); err == nil {
+		t.Errorf("no error for a single address")
+	}
+}
+
+func TestIPv4RangeCIDRs(t *testing.T) {
+	r := IPv4Range{Low: IPv4Addr{10, 0, 0, 1}, High: IPv4Addr{10, 0, 0, 6}}
+	want := []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"}
+	prefixes, approx := r.CIDRsLimited(4)
+	if approx || len(prefixes) != len(want) {
+		t.Fatalf("invalid cidrs: actual=%v want=%v", prefixes, want)
+	}
+	for i := range want {
+		if prefixes[i].String() != want[i] {
+			t.Errorf("invalid cidr: actual=%v want=%v", prefixes[i], want[i])
+		}
+	}
+
+	prefixes, approx = r.CIDRsLimited(3)
+	want = []string{"10.0.0.0/30", "10.0.0.4/31", "10.0.0.6/32"}
+	if !approx || len(prefixes) != len(want) {
+		t.Fatalf("invalid limited cidrs: actual=%v want=%v", prefixes, want)
+	}
+	for i := range want {
+		if prefixes[i].String() != want[i] {
+			t.Errorf("invalid cidr: actual=%v want=%v", prefixes[i], want[i])
+		}
+	}
+
+	// 10.0.0.0/29 adds as few addresses as 10.0.0.0/30 and 10.0.0.4/30.
+	prefixes, approx = r.CIDRsLimited(2)
+	if !approx || len(prefixes) != 1 || prefixes[0].String() != "10.0.0.0/29" {
+		t.Errorf("invalid limited cidrs: actual=%v want=[10.0.0.0/29]", prefixes)
+	}
+	for _, n := range []int{0, -1} {
+		if prefixes, approx := r.CIDRsLimited(n); !approx || prefixes != nil {
+			t.Errorf("invalid cidrs limited to %d: actual=%v,%v want=[],true", n,
+				prefixes, approx)
+		}
+	}
+
+	all := IPv4Range{High: MaskNoneIPV4}
+	if prefixes := all.CIDRs(); len(prefixes) != 1 ||
+		prefixes[0].String() != "0.0.0.0/0" {
+
+		t.Errorf("invalid cidrs for the whole space: %v", prefixes)
+	}
+}
+
+func TestMergeIPv4Ranges(t *testing.T) {
+	var ranges []IPv4Range
+	for _, s := range []string{
+		"10.0.0.50-10.0.0.60", // Disjoint.
+		"10.0.0.5-10.0.0.20",  // Overlaps the next one.
+		"10.0.0.1-10.0.0.9",   // Overlaps the previous one.
+		"10.0.0.21-10.0.0.30", // Adjacent to the previous ones.
+		"10.0.0.55-10.0.0.58", // Contained in the first one.
+		"255.255.255.0-255.255.255.255",
+	} {
+		r, _ := ParseIPv4Range(s)
+		ranges = append(ranges, r)
+	}
+	merged := MergeIPv4Ranges(ranges)
+	want := []string{"10.0.0.1-10.0.0.30", "10.0.0.50-10.0.0.60",
+		"255.255.255.0-255.255.255.255"}
+	if len(merged) != len(want) {
+		t.Fatalf("invalid merged ranges: actual=%v want=%v", merged, want)
+	}
+	for i := range want {
+		if merged[i].String() != want[i] {
+			t.Errorf("invalid merged range: actual=%v want=%v", merged[i], want[i])
+		}
+	}
+	if ranges[0].String() != "10.0.0.50-10.0.0.60" {
+		t.Errorf("merge modified the input: %v", ranges)
+	}
+}
+
+func TestMergeIPv6Ranges(t *testing.T) {
+	var ranges []IPv6Range
+	for _, s := range []string{"2001:db8::10-2001:db8::1f",
+		"2001:db8::1-2001:db8::f", "2001:db8::100-2001:db8::200"} {
+		r, _ := ParseIPv6Range(s)
+		ranges = append(ranges, r)
+	}
+	merged := MergeIPv6Ranges(ranges)
+	if len(merged) != 2 || merged[0].String() != "2001:db8::1-2001:db8::1f" {
+		t.Errorf("invalid merged ranges: %v", merged)
+	}
+}
+
+func TestMergeMACRanges(t *testing.T) {
+	var ranges []MACRange
+	for _, s := range []string{"00:00:5e:00:01:10-00:00:5e:00:01:ff",
+		"00:00:5e:00:01:00-00:00:5e:00:01:20",
+		"00:00:5e:00:03:00-00:00:5e:00:03:ff"} {
+		r, _ := ParseMACRange(s)
+		ranges = append(ranges, r)
+	}
+	merged := MergeMACRanges(ranges)
+	if len(merged) != 2 ||
+		merged[0].String() != "00:00:5e:00:01:00-00:00:5e:00:01:ff" {
+		t.Errorf("invalid merged ranges: %v", merged)
+	}
+}
+
+func TestValidateIPv4Pools(t *testing.T) {
+	parse := func(ss ...string) []IPv4Range {
+		var pools []IPv4Range
+		for _, s := range ss {
+			r, _ := ParseIPv4Range(s)
+			pools = append(pools, r)
+		}
+		return pools
+	}
+
+	disjoint := parse("10.0.0.100-10.0.0.200", "10.0.0.1-10.0.0.99",
+		"10.0.1.1-10.0.1.99")
+	if err := ValidateIPv4Pools(disjoint); err != nil {
+		t.Errorf("invalid error for disjoint pools: %v", err)
+	}
+
+	overlapping := parse("10.0.0.1-10.0.0.50", "10.0.1.1-10.0.1.99",
+		"10.0.0.40-10.0.0.60", "10.0.0.60-10.0.0.70")
+	err := ValidateIPv4Pools(overlapping)
+	if err == nil {
+		t.Fatalf("no error for overlapping pools")
+	}
+	want := "overlapping pools #0 (10.0.0.1-10.0.0.50) and #2 " +
+		"(10.0.0.40-10.0.0.60), overlapping pools #2 (10.0.0.40-10.0.0.60) " +
+		"and #3 (10.0.0.60-10.0.0.70)"
+	if err.Error() != want {
+		t.Errorf("invalid error: actual=%q want=%q", err, want)
+	}
+
+	inverted := IPv4Range{Low: IPv4Addr{10, 0, 0, 9}, High: IPv4Addr{10, 0, 0, 1}}
+	if err := ValidateIPv4Pools([]IPv4Range{inverted}); err == nil {
+		t.Errorf("no error for an inverted pool")
+	}
+}
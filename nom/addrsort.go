@@ -0,0 +1,36 @@
+package nom
+
+import "sort"
+
+// SortMaskedIPv4s sorts s in the order of MaskedIPv4Addr.Less.
+func SortMaskedIPv4s(s []MaskedIPv4Addr) {
+	sort.Sort(maskedIPv4s(s))
+}
+
+// SortMaskedIPv6s sorts s in the order of MaskedIPv6Addr.Less.
+func SortMaskedIPv6s(s []MaskedIPv6Addr) {
+	sort.Sort(maskedIPv6s(s))
+}
+
+// SortMaskedMACs sorts s in the order of MaskedMACAddr.Less.
+func SortMaskedMACs(s []MaskedMACAddr) {
+	sort.Sort(maskedMACs(s))
+}
+
+type maskedIPv4s []MaskedIPv4Addr
+
+func (s maskedIPv4s) Len() int           { return len(s) }
+func (s maskedIPv4s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s maskedIPv4s) Less(i, j int) bool { return s[i].Less(s[j]) }
+
+type maskedIPv6s []MaskedIPv6Addr
+
+func (s maskedIPv6s) Len() int           { return len(s) }
+func (s maskedIPv6s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s maskedIPv6s) Less(i, j int) bool { return s[i].Less(s[j]) }
+
+type maskedMACs []MaskedMACAddr
+
+func (s maskedMACs) Len() int           { return len(s) }
+func (s maskedMACs) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s maskedMACs) Less(i, j int) bool { return s[i].Less(s[j]) }
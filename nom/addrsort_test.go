@@ -0,0 +1,49 @@
+package nom
+
+import "testing"
+
+func TestSortMaskedIPv4s(t *testing.T) {
+	var s []MaskedIPv4Addr
+	for _, p := range []string{"10.0.0.1/32", "10.0.0.0/16", "9.0.0.0/8",
+		"10.0.0.0/8", "10.0.0.5/16", "10.1.0.0/16"} {
+
+		mi, _ := ParseCIDRv4(p)
+		s = append(s, mi)
+	}
+	SortMaskedIPv4s(s)
+	want := []string{"9.0.0.0/8", "10.0.0.0/8", "10.0.0.0/16", "10.0.0.5/16",
+		"10.0.0.1/32", "10.1.0.0/16"}
+	for i := range want {
+		if s[i].String() != want[i] {
+			t.Errorf("invalid order at %d: actual=%v want=%v", i, s, want)
+			break
+		}
+	}
+}
+
+func TestSortMaskedIPv6s(t *testing.T) {
+	var s []MaskedIPv6Addr
+	for _, p := range []string{"2001:db8::/48", "2001:db8::/32", "::/0"} {
+		mi, _ := ParseCIDRv6(p)
+		s = append(s, mi)
+	}
+	SortMaskedIPv6s(s)
+	want := []string{"::/0", "2001:db8::/32", "2001:db8::/48"}
+	for i := range want {
+		if s[i].String() != want[i] {
+			t.Errorf("invalid order at %d: actual=%v want=%v", i, s, want)
+			break
+		}
+	}
+}
+
+func TestSortMaskedMACs(t *testing.T) {
+	mac := MACAddr{0, 0x11, 0x22, 0x33, 0x44, 0x55}
+	exact := MaskedMACAddr{Addr: mac, Mask: MaskNoneMAC}
+	oui := MaskedMACAddr{Addr: mac, Mask: MACAddr{0xFF, 0xFF, 0xFF}}
+	s := []MaskedMACAddr{exact, oui}
+	SortMaskedMACs(s)
+	if s[0] != oui || s[1] != exact {
+		t.Errorf("invalid order: actual=%v want=[%v %v]", s, oui, exact)
+	}
+}
@@ -0,0 +1,80 @@
+package nom
+
+// AggregateIPv4 summarizes prefixes into the smallest set of prefixes that
+// covers exactly the same addresses: host bits are cleared, prefixes within
+// other prefixes are dropped, and sibling prefixes are merged into their
+// parent (eg, 10.0.0.0/25 and 10.0.0.128/25 into 10.0.0.0/24). The result is
+// sorted by address. Masks are assumed to be contiguous.
+func AggregateIPv4(prefixes []MaskedIPv4Addr) []MaskedIPv4Addr {
+	sorted := make([]MaskedIPv4Addr, len(prefixes))
+	for i, mi := range prefixes {
+		sorted[i] = mi.Canonicalize()
+	}
+	SortMaskedIPv4s(sorted)
+
+	var agg []MaskedIPv4Addr
+	for _, mi := range sorted {
+		if len(agg) != 0 && agg[len(agg)-1].Subsumes(mi) {
+			continue
+		}
+		agg = append(agg, mi)
+		for n := len(agg); n >= 2 && agg[n-2].IsSiblingOf(agg[n-1]); n-- {
+			agg[n-2] = agg[n-2].CommonPrefix(agg[n-1])
+			agg = agg[:n-1]
+		}
+	}
+	return agg
+}
+
+// AggregateIPv6 summarizes IPv6 prefixes. See AggregateIPv4.
+func AggregateIPv6(prefixes []MaskedIPv6Addr) []MaskedIPv6Addr {
+	sorted := make([]MaskedIPv6Addr, len(prefixes))
+	for i, mi := range prefixes {
+		sorted[i] = mi.Canonicalize()
+	}
+	SortMaskedIPv6s(sorted)
+
+	var agg []MaskedIPv6Addr
+	for _, mi := range sorted {
+		if len(agg) != 0 && agg[len(agg)-1].Subsumes(mi) {
+			continue
+		}
+		agg = append(agg, mi)
+		for n := len(agg); n >= 2 && agg[n-2].IsSiblingOf(agg[n-1]); n-- {
+			agg[n-2] = agg[n-2].CommonPrefix(agg[n-1])
+			agg = agg[:n-1]
+		}
+	}
+	return agg
+}
+
+// AggregateAll summarizes a mixed list of IPv4 and IPv6 prefixes, such as a
+// dual-stack route table. Prefixes are grouped by family and each group is
+// aggregated by AggregateIPv4 or AggregateIPv6, so prefixes of different
+// families never merge. The result has the IPv4 prefixes first and then the
+// IPv6 prefixes. Masked MAC addresses are not aggregated, and are appended to
+// the result as they are.
+func AggregateAll(prefixes []MaskedAddr) []MaskedAddr {
+	var v4 []MaskedIPv4Addr
+	var v6 []MaskedIPv6Addr
+	var others []MaskedAddr
+	for _, p := range prefixes {
+		switch mi := p.(type) {
+		case MaskedIPv4Addr:
+			v4 = append(v4, mi)
+		case MaskedIPv6Addr:
+			v6 = append(v6, mi)
+		default:
+			others = append(others, p)
+		}
+	}
+
+	agg := make([]MaskedAddr, 0, len(prefixes))
+	for _, mi := range AggregateIPv4(v4) {
+		agg = append(agg, mi)
+	}
+	for _, mi := range AggregateIPv6(v6) {
+		agg = append(agg, mi)
+	}
+	return append(agg, others...)
+}
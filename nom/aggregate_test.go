@@ -0,0 +1,55 @@
+package nom
+
+import "testing"
+
+func TestAggregateIPv4(t *testing.T) {
+	var in []MaskedIPv4Addr
+	for _, p := range []string{"10.0.0.128/26", "10.0.0.0/25", "10.0.0.192/26",
+		"10.0.1.0/24", "10.0.0.5/32", "192.168.0.0/24", "192.168.2.0/24"} {
+
+		mi, _ := ParseCIDRv4(p)
+		in = append(in, mi)
+	}
+	agg := AggregateIPv4(in)
+	want := []string{"10.0.0.0/23", "192.168.0.0/24", "192.168.2.0/24"}
+	if len(agg) != len(want) {
+		t.Fatalf("invalid aggregation: actual=%v want=%v", agg, want)
+	}
+	for i := range want {
+		if agg[i].String() != want[i] {
+			t.Errorf("invalid aggregation: actual=%v want=%v", agg, want)
+			break
+		}
+	}
+}
+
+func TestAggregateAll(t *testing.T) {
+	var in []MaskedAddr
+	for _, p := range []string{"2001:db8:1::/48", "10.0.0.0/25",
+		"2001:db8::/48", "10.0.0.128/25", "8000::/1"} {
+
+		if mi, err := ParseCIDRv4(p); err == nil {
+			in = append(in, mi)
+			continue
+		}
+		mi, _ := ParseCIDRv6(p)
+		in = append(in, mi)
+	}
+	in = append(in, MaskedMACAddr{Mask: MaskNoneMAC})
+
+	agg := AggregateAll(in)
+	want := []string{"10.0.0.0/24", "2001:db8::/47", "8000::/1",
+		"00:00:00:00:00:00/ff:ff:ff:ff:ff:ff"}
+	if len(agg) != len(want) {
+		t.Fatalf("invalid aggregation: actual=%v want=%v", agg, want)
+	}
+	for i := range want {
+		if agg[i].String() != want[i] {
+			t.Errorf("invalid aggregation: actual=%v want=%v", agg, want)
+			break
+		}
+	}
+	if agg[0].Family() != AddrFamilyIPv4 || agg[1].Family() != AddrFamilyIPv6 {
+		t.Errorf("invalid families in %v", agg)
+	}
+}
@@ -0,0 +1,88 @@
+package nom
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// Anonymize returns ip with all bits beyond prefixLen cleared. For example,
+// 10.1.2.3 is anonymized to 10.1.2.0 with a prefix length of 24.
+//
+// Anonymization is lossy and irreversible: it is meant for exporting flow
+// statistics and telemetry without leaking the identity of end-points.
+func (ip IPv4Addr) Anonymize(prefixLen int) IPv4Addr {
+	return ip.Mask(MaskFromPrefixLen4(prefixLen))
+}
+
+// Anonymize returns ip with all bits beyond prefixLen cleared. Similar to
+// IPv4Addr.Anonymize, this is lossy and irreversible.
+func (ip IPv6Addr) Anonymize(prefixLen int) IPv6Addr {
+	return ip.Mask(MaskFromPrefixLen6(prefixLen))
+}
+
+// Anonymize returns m with its NIC-specific bytes cleared, keeping only the
+// OUI that identifies the vendor. Similar to IPv4Addr.Anonymize, this is lossy
+// and irreversible.
+func (m MACAddr) Anonymize() MACAddr {
+	return MACAddr{m[0], m[1], m[2]}
+}
+
+// pseudonymRounds is the number of Feistel rounds of PseudonymizeIPv4.
+const pseudonymRounds = 8
+
+// pseudonymCipher returns the AES block cipher of key, which is built once per
+// call to PseudonymizeIPv4 and shared by all its rounds.
+func pseudonymCipher(key [16]byte) cipher.Block {
+	// aes.NewCipher only fails for invalid key sizes.
+	c, _ := aes.NewCipher(key[:])
+	return c
+}
+
+// pseudonymRound returns the round function of PseudonymizeIPv4 for round r
+// and the 16-bit half h: the first 16 bits of the AES encryption of (r, h).
+func pseudonymRound(c cipher.Block, r int, h uint16) uint16 {
+	var b [aes.BlockSize]byte
+	b[0] = byte(r)
+	binary.BigEndian.PutUint16(b[1:], h)
+	c.Encrypt(b[:], b[:])
+	return binary.BigEndian.Uint16(b[:])
+}
+
+// PseudonymizeIPv4 maps ip to a pseudonym using a keyed permutation of the
+// IPv4 address space: under the same key, an address always has the same
+// pseudonym and two addresses never share one. This allows sharing flow data
+// without exposing real addresses while preserving joins on addresses. Use
+// DepseudonymizeIPv4 with the same key to recover the address.
+//
+// The permutation is a Feistel network with AES as its round function. Unlike
+// Anonymize, it does not preserve prefixes and it is reversible by whoever has
+// the key, and it is not a substitute for strong anonymization: traffic
+// patterns can still reveal well-known addresses.
+func PseudonymizeIPv4(ip IPv4Addr, key [16]byte) IPv4Addr {
+	l := binary.BigEndian.Uint16(ip[:2])
+	r := binary.BigEndian.Uint16(ip[2:])
+	c := pseudonymCipher(key)
+	for i := 0; i < pseudonymRounds; i++ {
+		l, r = r, l^pseudonymRound(c, i, r)
+	}
+	var p IPv4Addr
+	binary.BigEndian.PutUint16(p[:2], l)
+	binary.BigEndian.PutUint16(p[2:], r)
+	return p
+}
+
+// DepseudonymizeIPv4 returns the address whose pseudonym is p under key. It is
+// the inverse of PseudonymizeIPv4.
+func DepseudonymizeIPv4(p IPv4Addr, key [16]byte) IPv4Addr {
+	l := binary.BigEndian.Uint16(p[:2])
+	r := binary.BigEndian.Uint16(p[2:])
+	c := pseudonymCipher(key)
+	for i := pseudonymRounds - 1; i >= 0; i-- {
+		l, r = r^pseudonymRound(c, i, l), l
+	}
+	var ip IPv4Addr
+	binary.BigEndian.PutUint16(ip[:2], l)
+	binary.BigEndian.PutUint16(ip[2:], r)
+	return ip
+}
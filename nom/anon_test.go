@@ -0,0 +1,50 @@
+package nom
+
+import "testing"
+
+func TestAnonymize(t *testing.T) {
+	ip := IPv4Addr{10, 1, 2, 3}
+	if a := ip.Anonymize(24); a != (IPv4Addr{10, 1, 2, 0}) {
+		t.Errorf("invalid anonymized address: actual=%v want=10.1.2.0", a)
+	}
+	if a := ip.Anonymize(0); a != (IPv4Addr{}) {
+		t.Errorf("invalid anonymized address: actual=%v want=0.0.0.0", a)
+	}
+
+	ip6 := IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 0x12, 0x34, 15: 1}
+	if a := ip6.Anonymize(32); a.String() != "2001:db8::" {
+		t.Errorf("invalid anonymized address: actual=%v want=2001:db8::", a)
+	}
+
+	mac := MACAddr{0x00, 0x1b, 0x21, 0x3a, 0x4b, 0x5c}
+	if a := mac.Anonymize(); a != (MACAddr{0x00, 0x1b, 0x21}) {
+		t.Errorf("invalid anonymized address: actual=%v want=00:1b:21:00:00:00",
+			a)
+	}
+}
+
+func TestPseudonymizeIPv4(t *testing.T) {
+	key := [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	other := [16]byte{15: 1}
+	seen := make(map[IPv4Addr]IPv4Addr)
+	for i := uint32(0); i < 4096; i++ {
+		var ip IPv4Addr
+		ip.FromUint(0x0A000000 + i*977)
+		p := PseudonymizeIPv4(ip, key)
+		if q, ok := seen[p]; ok {
+			t.Fatalf("%v and %v have the same pseudonym %v", ip, q, p)
+		}
+		seen[p] = ip
+		if PseudonymizeIPv4(ip, key) != p {
+			t.Errorf("unstable pseudonym for %v", ip)
+		}
+		if d := DepseudonymizeIPv4(p, key); d != ip {
+			t.Errorf("invalid round trip: actual=%v want=%v", d, ip)
+		}
+	}
+
+	ip := IPv4Addr{10, 0, 0, 1}
+	if PseudonymizeIPv4(ip, key) == PseudonymizeIPv4(ip, other) {
+		t.Errorf("the pseudonym of %v does not depend on the key", ip)
+	}
+}
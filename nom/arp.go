@@ -0,0 +1,183 @@
+package nom
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ARPEntry is a binding of an IPv4 address to a MAC address, as learned from
+// ARP.
+type ARPEntry struct {
+	IP       IPv4Addr
+	MAC      MACAddr
+	LastSeen time.Time
+}
+
+// ARPCache stores the most recent binding of each IPv4 address to a MAC
+// address, and supports reverse lookups of the addresses bound to a MAC
+// address. The zero value is not usable; use NewARPCache to create one.
+//
+// ARPCache is safe for concurrent use.
+type ARPCache struct {
+	mu    sync.RWMutex
+	byIP  map[IPv4Addr]ARPEntry
+	byMAC map[MACAddr]map[IPv4Addr]struct{}
+	// The MAC addresses seen for each IP address in the last sharedWindow,
+	// along with the time they were last seen.
+	recent       map[IPv4Addr]map[MACAddr]time.Time
+	sharedWindow time.Duration
+}
+
+// DefaultARPSharedWindow is the window of the caches created by NewARPCache.
+// See NewARPCacheWindow.
+const DefaultARPSharedWindow = 10 * time.Second
+
+// NewARPCache creates an empty ARP cache whose shared window is
+// DefaultARPSharedWindow.
+func NewARPCache() *ARPCache {
+	return NewARPCacheWindow(DefaultARPSharedWindow)
+}
+
+// NewARPCacheWindow creates an empty ARP cache that reports, in SharedIPs, the
+// IP addresses seen with more than one MAC address within window.
+func NewARPCacheWindow(window time.Duration) *ARPCache {
+	return &ARPCache{
+		byIP:         make(map[IPv4Addr]ARPEntry),
+		byMAC:        make(map[MACAddr]map[IPv4Addr]struct{}),
+		recent:       make(map[IPv4Addr]map[MACAddr]time.Time),
+		sharedWindow: window,
+	}
+}
+
+// Len returns the number of IP addresses in the cache.
+func (c *ARPCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.byIP)
+}
+
+// Update binds ip to mac and refreshes the LastSeen time of the binding. If
+// ip was bound to another MAC address (eg, because the host has moved or its
+// NIC is replaced), the previous binding is replaced.
+func (c *ARPCache) Update(ip IPv4Addr, mac MACAddr) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.byIP[ip]; ok && e.MAC != mac {
+		c.unbind(e.MAC, ip)
+	}
+	now := time.Now()
+	c.byIP[ip] = ARPEntry{IP: ip, MAC: mac, LastSeen: now}
+	c.seen(ip, mac, now)
+	ips, ok := c.byMAC[mac]
+	if !ok {
+		ips = make(map[IPv4Addr]struct{})
+		c.byMAC[mac] = ips
+	}
+	ips[ip] = struct{}{}
+}
+
+func (c *ARPCache) unbind(mac MACAddr, ip IPv4Addr) {
+	ips := c.byMAC[mac]
+	delete(ips, ip)
+	if len(ips) == 0 {
+		delete(c.byMAC, mac)
+	}
+}
+
+// seen records that ip is seen with mac at now, and forgets the MAC addresses
+// of ip that are not seen in the shared window.
+func (c *ARPCache) seen(ip IPv4Addr, mac MACAddr, now time.Time) {
+	c.expire(ip, now)
+	macs, ok := c.recent[ip]
+	if !ok {
+		macs = make(map[MACAddr]time.Time)
+		c.recent[ip] = macs
+	}
+	macs[mac] = now
+}
+
+// expire forgets the MAC addresses of ip that are not seen in the shared
+// window at now, and forgets ip once none is left.
+func (c *ARPCache) expire(ip IPv4Addr, now time.Time) {
+	macs := c.recent[ip]
+	for m, t := range macs {
+		if now.Sub(t) > c.sharedWindow {
+			delete(macs, m)
+		}
+	}
+	if len(macs) == 0 {
+		delete(c.recent, ip)
+	}
+}
+
+// SharedIPs returns, in ascending order, the IP addresses that are seen with
+// more than one MAC address in the shared window of the cache (see
+// NewARPCacheWindow). This indicates either an anycast address or a
+// duplicate-IP misconfiguration. It also forgets the expired history of all
+// IP addresses, so that the history of idle addresses does not grow the
+// cache.
+func (c *ARPCache) SharedIPs() []IPv4Addr {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var ips []IPv4Addr
+	for ip := range c.recent {
+		c.expire(ip, now)
+		if len(c.recent[ip]) > 1 {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Sort(ipv4Addrs(ips))
+	return ips
+}
+
+// Lookup returns the MAC address bound to ip.
+func (c *ARPCache) Lookup(ip IPv4Addr) (MACAddr, bool) {
+	e, ok := c.Entry(ip)
+	return e.MAC, ok
+}
+
+// Entry returns the ARP entry of ip.
+func (c *ARPCache) Entry(ip IPv4Addr) (ARPEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.byIP[ip]
+	return e, ok
+}
+
+// ReverseLookup returns the IP addresses bound to mac in ascending order.
+func (c *ARPCache) ReverseLookup(mac MACAddr) []IPv4Addr {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ips := make([]IPv4Addr, 0, len(c.byMAC[mac]))
+	for ip := range c.byMAC[mac] {
+		ips = append(ips, ip)
+	}
+	sort.Sort(ipv4Addrs(ips))
+	return ips
+}
+
+type ipv4Addrs []IPv4Addr
+
+func (a ipv4Addrs) Len() int           { return len(a) }
+func (a ipv4Addrs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ipv4Addrs) Less(i, j int) bool { return a[i].Less(a[j]) }
+
+// IsGratuitousARP returns whether an ARP packet with the given sender and
+// target fields is a gratuitous ARP, ie, an announcement of the binding of
+// senderIP to senderMAC rather than a query. A gratuitous ARP has the same
+// sender and target IP addresses, and its target MAC address is either zero
+// (in requests) or broadcast (in replies). ARP probes (RFC 5227), whose sender
+// IP address is unspecified, are not gratuitous, and neither are packets from
+// group MAC addresses, which cannot own an IP address.
+func IsGratuitousARP(senderIP, targetIP IPv4Addr, senderMAC,
+	targetMAC MACAddr) bool {
+
+	if senderIP != targetIP || senderIP.IsUnspecified() || senderMAC.IsGroup() {
+		return false
+	}
+	return targetMAC == MACAddr{} || targetMAC.IsBroadcast()
+}
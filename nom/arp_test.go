@@ -0,0 +1,132 @@
+package nom
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestARPCacheHostMove(t *testing.T) {
+	c := NewARPCache()
+	ip := IPv4Addr{10, 0, 0, 1}
+	mac1 := MACAddr{0, 0, 0, 0, 0, 1}
+	mac2 := MACAddr{0, 0, 0, 0, 0, 2}
+
+	c.Update(ip, mac1)
+	c.Update(IPv4Addr{10, 0, 0, 2}, mac1)
+	if mac, ok := c.Lookup(ip); !ok || mac != mac1 {
+		t.Errorf("invalid binding of %v: actual=%v want=%v", ip, mac, mac1)
+	}
+	first, _ := c.Entry(ip)
+
+	c.Update(ip, mac2)
+	if mac, ok := c.Lookup(ip); !ok || mac != mac2 {
+		t.Errorf("invalid binding of %v after move: actual=%v want=%v", ip, mac,
+			mac2)
+	}
+	if e, _ := c.Entry(ip); e.LastSeen.Before(first.LastSeen) {
+		t.Errorf("last seen time is not refreshed: %v", e.LastSeen)
+	}
+	if ips := c.ReverseLookup(mac1); len(ips) != 1 ||
+		ips[0] != (IPv4Addr{10, 0, 0, 2}) {
+		t.Errorf("invalid reverse lookup of %v: %v", mac1, ips)
+	}
+	if ips := c.ReverseLookup(mac2); len(ips) != 1 || ips[0] != ip {
+		t.Errorf("invalid reverse lookup of %v: %v", mac2, ips)
+	}
+	if c.Len() != 2 {
+		t.Errorf("invalid cache size: actual=%v want=2", c.Len())
+	}
+
+	c.Update(IPv4Addr{10, 0, 0, 2}, mac2)
+	if ips := c.ReverseLookup(mac1); len(ips) != 0 {
+		t.Errorf("%v should have no addresses: %v", mac1, ips)
+	}
+	if ips := c.ReverseLookup(mac2); len(ips) != 2 || ips[0] != ip {
+		t.Errorf("invalid reverse lookup of %v: %v", mac2, ips)
+	}
+}
+
+func TestARPCacheSharedIPs(t *testing.T) {
+	c := NewARPCache()
+	single := IPv4Addr{10, 0, 0, 1}
+	shared := IPv4Addr{10, 0, 0, 2}
+	mac1 := MACAddr{0, 0, 0, 0, 0, 1}
+	mac2 := MACAddr{0, 0, 0, 0, 0, 2}
+
+	c.Update(single, mac1)
+	c.Update(single, mac1)
+	c.Update(shared, mac1)
+	c.Update(shared, mac2)
+	ips := c.SharedIPs()
+	if len(ips) != 1 || ips[0] != shared {
+		t.Errorf("invalid shared IPs: actual=%v want=[%v]", ips, shared)
+	}
+
+	// Age out mac1 for shared, and all the history of single.
+	c.recent[shared][mac1] = time.Now().Add(-2 * DefaultARPSharedWindow)
+	c.recent[single][mac1] = time.Now().Add(-2 * DefaultARPSharedWindow)
+	if ips := c.SharedIPs(); len(ips) != 0 {
+		t.Errorf("invalid shared IPs after the window: %v", ips)
+	}
+	if _, ok := c.recent[single]; ok || len(c.recent) != 1 {
+		t.Errorf("expired history is not pruned: %v", c.recent)
+	}
+}
+
+func TestARPCacheSharedWindow(t *testing.T) {
+	c := NewARPCacheWindow(time.Nanosecond)
+	ip := IPv4Addr{10, 0, 0, 1}
+	c.Update(ip, MACAddr{0, 0, 0, 0, 0, 1})
+	time.Sleep(time.Millisecond)
+	c.Update(ip, MACAddr{0, 0, 0, 0, 0, 2})
+	if ips := c.SharedIPs(); len(ips) != 0 {
+		t.Errorf("invalid shared IPs outside of the window: %v", ips)
+	}
+}
+
+func TestARPCacheConcurrent(t *testing.T) {
+	c := NewARPCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ip := IPv4Addr{10, 0, 0, byte(j)}
+				c.Update(ip, MACAddr{0, 0, 0, 0, 0, byte(i)})
+				c.Lookup(ip)
+				c.ReverseLookup(MACAddr{0, 0, 0, 0, 0, byte(i)})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if c.Len() != 100 {
+		t.Errorf("invalid cache size: actual=%v want=100", c.Len())
+	}
+}
+
+func TestIsGratuitousARP(t *testing.T) {
+	ip := IPv4Addr{10, 0, 0, 1}
+	mac := MACAddr{0, 0x11, 0x22, 0x33, 0x44, 0x55}
+	for _, tc := range []struct {
+		name       string
+		sip, tip   IPv4Addr
+		smac, tmac MACAddr
+		gratuitous bool
+	}{
+		{"gratuitous request", ip, ip, mac, MACAddr{}, true},
+		{"gratuitous reply", ip, ip, mac, BroadcastMAC, true},
+		{"probe", IPv4Addr{}, ip, mac, MACAddr{}, false},
+		{"request", ip, IPv4Addr{10, 0, 0, 2}, mac, MACAddr{}, false},
+		{"reply", ip, IPv4Addr{10, 0, 0, 2}, mac, mac.Next(), false},
+		{"self-addressed reply", ip, ip, mac, mac.Next(), false},
+		{"group sender", ip, ip, BroadcastMAC, MACAddr{}, false},
+	} {
+		if g := IsGratuitousARP(tc.sip, tc.tip, tc.smac, tc.tmac); g !=
+			tc.gratuitous {
+			t.Errorf("invalid detection of %s: actual=%v want=%v", tc.name, g,
+				tc.gratuitous)
+		}
+	}
+}
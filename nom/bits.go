@@ -0,0 +1,185 @@
+package nom
+
+// addrBits provides bit-level access to the big-endian bytes of an address.
+// It is the common building block of the tries and the prefix algorithms.
+type addrBits []byte
+
+// BitAt returns the i'th most significant bit of b.
+func (b addrBits) BitAt(i int) int {
+	return int(b[i/8]>>(7-uint(i%8))) & 0x1
+}
+
+// CommonPrefixLen returns the number of leading bits that b and other share.
+func (b addrBits) CommonPrefixLen(other addrBits) int {
+	l := 0
+	for i := 0; i < len(b) && i < len(other); i++ {
+		x := b[i] ^ other[i]
+		if x == 0 {
+			l += 8
+			continue
+		}
+		for x&0x80 == 0 {
+			x <<= 1
+			l++
+		}
+		break
+	}
+	return l
+}
+
+// DiffBits returns the number of bits that differ between b and other (ie,
+// their Hamming distance). b and other must have the same length.
+func (b addrBits) DiffBits(other addrBits) int {
+	n := 0
+	for i := range b {
+		for x := b[i] ^ other[i]; x != 0; x &= x - 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// FirstDiffBit returns the index of the most significant bit that differs
+// between b and other, or -1 if they are equal.
+func (b addrBits) FirstDiffBit(other addrBits) int {
+	c := b.CommonPrefixLen(other)
+	if c == 8*len(b) {
+		return -1
+	}
+	return c
+}
+
+// PrefixCompare compares the first l bits of b and other, and returns -1, 0,
+// or 1 if those bits of b are respectively less than, equal to, or greater
+// than the bits of other.
+func (b addrBits) PrefixCompare(other addrBits, l int) int {
+	c := b.CommonPrefixLen(other)
+	if c >= l {
+		return 0
+	}
+	if b.BitAt(c) == 0 {
+		return -1
+	}
+	return 1
+}
+
+// Masked returns a copy of b with all bits beyond the first l bits cleared.
+func (b addrBits) Masked(l int) addrBits {
+	masked := make(addrBits, len(b))
+	copy(masked, b)
+	for i := range masked {
+		switch {
+		case l >= 8*(i+1):
+		case l <= 8*i:
+			masked[i] = 0
+		default:
+			masked[i] &= ^byte(0xFF >> uint(l-8*i))
+		}
+	}
+	return masked
+}
+
+// SetBits returns the indices of the bits set in b, from the most significant
+// bit (index 0).
+func (b addrBits) SetBits() []int {
+	return b.bitsEqualTo(1)
+}
+
+// ClearBits returns the indices of the bits cleared in b, from the most
+// significant bit (index 0).
+func (b addrBits) ClearBits() []int {
+	return b.bitsEqualTo(0)
+}
+
+func (b addrBits) bitsEqualTo(v int) []int {
+	var bits []int
+	for i := 0; i < 8*len(b); i++ {
+		if b.BitAt(i) == v {
+			bits = append(bits, i)
+		}
+	}
+	return bits
+}
+
+// CommonPrefixLen returns the number of leading bits shared by the big-endian
+// byte slices a and b. For example, it returns 23 for the bytes of 10.0.0.0 and
+// 10.0.1.0.
+func CommonPrefixLen(a, b []byte) int {
+	return addrBits(a).CommonPrefixLen(b)
+}
+
+// DiffBits returns the number of bits that differ between ip and other. For
+// example, it returns 2 for 10.0.0.1 and 10.0.0.2.
+func (ip IPv4Addr) DiffBits(other IPv4Addr) int {
+	return addrBits(ip[:]).DiffBits(other[:])
+}
+
+// FirstDiffBit returns the index of the most significant bit that differs
+// between ip and other, or -1 if they are equal. An address matches a prefix
+// of length l iff its first differing bit with the prefix is not less than l
+// (or is -1), so this tells how close an address is to matching a prefix.
+// For example, it returns 23 for 10.0.0.0 and 10.0.1.0.
+func (ip IPv4Addr) FirstDiffBit(other IPv4Addr) int {
+	return addrBits(ip[:]).FirstDiffBit(other[:])
+}
+
+// DiffBits returns the number of bits that differ between ip and other.
+func (ip IPv6Addr) DiffBits(other IPv6Addr) int {
+	return addrBits(ip[:]).DiffBits(other[:])
+}
+
+// FirstDiffBit returns the index of the most significant bit that differs
+// between ip and other, or -1 if they are equal. See IPv4Addr.FirstDiffBit.
+func (ip IPv6Addr) FirstDiffBit(other IPv6Addr) int {
+	return addrBits(ip[:]).FirstDiffBit(other[:])
+}
+
+// DiffBits returns the number of bits that differ between m and other.
+func (m MACAddr) DiffBits(other MACAddr) int {
+	return addrBits(m[:]).DiffBits(other[:])
+}
+
+// FirstDiffBit returns the index of the most significant bit that differs
+// between m and other, or -1 if they are equal.
+func (m MACAddr) FirstDiffBit(other MACAddr) int {
+	return addrBits(m[:]).FirstDiffBit(other[:])
+}
+
+// MaskBits returns the indices of the bits set in the mask, from the most
+// significant bit (index 0); eg, 0 through 23 for a /24. For a non-contiguous
+// mask, the missing indices are the wildcard bits, which is what hardware
+// tables with arbitrary masks (eg, TCAMs) need.
+func (mi MaskedIPv4Addr) MaskBits() []int {
+	return addrBits(mi.Mask[:]).SetBits()
+}
+
+// MaskBits returns the indices of the bits set in the mask. See
+// MaskedIPv4Addr.MaskBits.
+func (mi MaskedIPv6Addr) MaskBits() []int {
+	return addrBits(mi.Mask[:]).SetBits()
+}
+
+// MaskBits returns the indices of the bits set in the mask. See
+// MaskedIPv4Addr.MaskBits.
+func (mm MaskedMACAddr) MaskBits() []int {
+	return addrBits(mm.Mask[:]).SetBits()
+}
+
+// DontCareBits returns the indices of the wildcard bits of the mask, ie, the
+// bits ignored when matching, from the most significant bit (index 0); eg, 24
+// through 31 for a /24. It is the complement of MaskBits.
+func (mi MaskedIPv4Addr) DontCareBits() []int {
+	return addrBits(mi.Mask[:]).ClearBits()
+}
+
+// DontCareBits returns the indices of the wildcard bits of the mask. See
+// MaskedIPv4Addr.DontCareBits.
+func (mi MaskedIPv6Addr) DontCareBits() []int {
+	return addrBits(mi.Mask[:]).ClearBits()
+}
+
+// DontCareBits returns the indices of the wildcard bits of the mask. See
+// MaskedIPv4Addr.DontCareBits.
+func (mm MaskedMACAddr) DontCareBits() []int {
+	return addrBits(mm.Mask[:]).ClearBits()
+}
@@ -0,0 +1,196 @@
+package nom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddrBits(t *testing.T) {
+	b := addrBits{0xA0, 0x01}
+	bits := []int{1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	for i, want := range bits {
+		if b.BitAt(i) != want {
+			t.Errorf("invalid bit %d: actual=%v want=%v", i, b.BitAt(i), want)
+		}
+	}
+
+	lens := map[[2]IPv4Addr]int{
+		{{10, 0, 0, 0}, {10, 0, 1, 0}}:       23,
+		{{10, 0, 0, 0}, {10, 0, 0, 0}}:       32,
+		{{0, 0, 0, 0}, {128, 0, 0, 0}}:       0,
+		{{192, 168, 0, 0}, {192, 169, 0, 0}}: 15,
+	}
+	for ips, want := range lens {
+		if l := CommonPrefixLen(ips[0][:], ips[1][:]); l != want {
+			t.Errorf("invalid common prefix length for %v and %v: actual=%v want=%v",
+				ips[0], ips[1], l, want)
+		}
+	}
+
+	a := addrBits{10, 0, 0, 0}
+	c := addrBits{10, 0, 1, 0}
+	if a.PrefixCompare(c, 23) != 0 || a.PrefixCompare(c, 24) != -1 ||
+		c.PrefixCompare(a, 24) != 1 {
+
+		t.Errorf("invalid prefix comparison of %v and %v", a, c)
+	}
+
+	if m := (addrBits{0xFF, 0xFF}).Masked(12); m[0] != 0xFF || m[1] != 0xF0 {
+		t.Errorf("invalid masked bits: actual=%v want=[255 240]", m)
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	a := CIDRToMaskedIPv4(0x0A000100, 24)
+	b := CIDRToMaskedIPv4(0x0A000200, 23)
+	if p := a.CommonPrefix(b); p != CIDRToMaskedIPv4(0x0A000000, 22) {
+		t.Errorf("invalid common prefix: actual=%v want=10.0.0.0/22", p)
+	}
+	c := CIDRToMaskedIPv4(0x0A000000, 16)
+	if p := a.CommonPrefix(c); p != c {
+		t.Errorf("invalid common prefix: actual=%v want=10.0.0.0/16", p)
+	}
+
+	p6 := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 0x80},
+		Mask: MaskFromPrefixLen6(48),
+	}
+	q6 := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8},
+		Mask: MaskFromPrefixLen6(48),
+	}
+	if p := p6.CommonPrefix(q6); p.String() != "2001:db8::/32" {
+		t.Errorf("invalid common prefix: actual=%v want=2001:db8::/32", p)
+	}
+}
+
+func TestIsSiblingOf(t *testing.T) {
+	a := CIDRToMaskedIPv4(0x0A000000, 25)
+	b := CIDRToMaskedIPv4(0x0A000080, 25)
+	c := CIDRToMaskedIPv4(0x0A000100, 25)
+	if !a.IsSiblingOf(b) || !b.IsSiblingOf(a) {
+		t.Errorf("%v and %v should be siblings", a, b)
+	}
+	if a.IsSiblingOf(a) || b.IsSiblingOf(c) {
+		t.Errorf("unexpected siblings")
+	}
+	if a.IsSiblingOf(CIDRToMaskedIPv4(0x0A000080, 26)) {
+		t.Errorf("prefixes of different lengths cannot be siblings")
+	}
+
+	p6 := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 0x80},
+		Mask: MaskFromPrefixLen6(33),
+	}
+	q6 := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8},
+		Mask: MaskFromPrefixLen6(33),
+	}
+	if !p6.IsSiblingOf(q6) {
+		t.Errorf("%v and %v should be siblings", p6, q6)
+	}
+}
+
+func TestDiffBits(t *testing.T) {
+	tests4 := []struct {
+		a, b        IPv4Addr
+		diff, first int
+	}{
+		{IPv4Addr{10, 0, 0, 1}, IPv4Addr{10, 0, 0, 2}, 2, 30},
+		{IPv4Addr{10, 0, 0, 0}, IPv4Addr{10, 0, 1, 0}, 1, 23},
+		{IPv4Addr{0, 0, 0, 0}, IPv4Addr{255, 255, 255, 255}, 32, 0},
+		{IPv4Addr{10, 1, 2, 3}, IPv4Addr{10, 1, 2, 3}, 0, -1},
+	}
+	for _, tc := range tests4 {
+		if d := tc.a.DiffBits(tc.b); d != tc.diff {
+			t.Errorf("invalid diff bits of %v and %v: actual=%v want=%v", tc.a,
+				tc.b, d, tc.diff)
+		}
+		if f := tc.a.FirstDiffBit(tc.b); f != tc.first {
+			t.Errorf("invalid first diff bit of %v and %v: actual=%v want=%v",
+				tc.a, tc.b, f, tc.first)
+		}
+	}
+
+	a, _ := ParseIPv6("2001:db8::1")
+	b, _ := ParseIPv6("2001:db8:8000::1")
+	if a.DiffBits(b) != 1 || a.FirstDiffBit(b) != 32 || a.FirstDiffBit(a) != -1 {
+		t.Errorf("invalid diff of %v and %v: %v %v", a, b, a.DiffBits(b),
+			a.FirstDiffBit(b))
+	}
+
+	m1 := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	m2 := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0xAA}
+	if m1.DiffBits(m2) != 8 || m1.FirstDiffBit(m2) != 40 {
+		t.Errorf("invalid diff of %v and %v: %v %v", m1, m2, m1.DiffBits(m2),
+			m1.FirstDiffBit(m2))
+	}
+}
+
+func TestMaskBits(t *testing.T) {
+	seq := func(from, to int) []int {
+		var s []int
+		for i := from; i < to; i++ {
+			s = append(s, i)
+		}
+		return s
+	}
+
+	p := CIDRToMaskedIPv4(0x0A000000, 24)
+	if b := p.MaskBits(); !reflect.DeepEqual(b, seq(0, 24)) {
+		t.Errorf("invalid mask bits of %v: %v", p, b)
+	}
+
+	nc := MaskedIPv4Addr{Mask: IPv4Addr{255, 0, 255, 1}}
+	want := append(append(seq(0, 8), seq(16, 24)...), 31)
+	if b := nc.MaskBits(); !reflect.DeepEqual(b, want) {
+		t.Errorf("invalid mask bits of %v: actual=%v want=%v", nc, b, want)
+	}
+
+	p6, _ := ParseCIDRv6("2001:db8::/32")
+	if b := p6.MaskBits(); !reflect.DeepEqual(b, seq(0, 32)) {
+		t.Errorf("invalid mask bits of %v: %v", p6, b)
+	}
+
+	mm := MaskedMACAddr{Mask: MACAddr{0x01}}
+	if b := mm.MaskBits(); !reflect.DeepEqual(b, []int{7}) {
+		t.Errorf("invalid mask bits of %v: %v", mm, b)
+	}
+	if b := (MaskedMACAddr{}).MaskBits(); len(b) != 0 {
+		t.Errorf("invalid mask bits of an empty mask: %v", b)
+	}
+}
+
+func TestDontCareBits(t *testing.T) {
+	seq := func(from, to int) []int {
+		var s []int
+		for i := from; i < to; i++ {
+			s = append(s, i)
+		}
+		return s
+	}
+
+	p := CIDRToMaskedIPv4(0x0A000000, 24)
+	if b := p.DontCareBits(); !reflect.DeepEqual(b, seq(24, 32)) {
+		t.Errorf("invalid don't-care bits of %v: %v", p, b)
+	}
+
+	nc := MaskedIPv4Addr{Mask: IPv4Addr{255, 0, 255, 1}}
+	want := append(seq(8, 16), seq(24, 31)...)
+	if b := nc.DontCareBits(); !reflect.DeepEqual(b, want) {
+		t.Errorf("invalid don't-care bits of %v: actual=%v want=%v", nc, b, want)
+	}
+
+	p6, _ := ParseCIDRv6("2001:db8::/120")
+	if b := p6.DontCareBits(); !reflect.DeepEqual(b, seq(120, 128)) {
+		t.Errorf("invalid don't-care bits of %v: %v", p6, b)
+	}
+
+	mm := MaskedMACAddr{Mask: BroadcastMAC}
+	if b := mm.DontCareBits(); len(b) != 0 {
+		t.Errorf("invalid don't-care bits of an exact mask: %v", b)
+	}
+	if b := (MaskedMACAddr{}).DontCareBits(); !reflect.DeepEqual(b, seq(0, 48)) {
+		t.Errorf("invalid don't-care bits of an empty mask: %v", b)
+	}
+}
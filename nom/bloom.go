@@ -0,0 +1,77 @@
+package nom
+
+import (
+	"math"
+	"sync"
+)
+
+// IPv4BloomFilter is a Bloom filter of IPv4 addresses. It answers whether an
+// address may be in a set using a fraction of the memory of the set: it never
+// misses an added address, but can report an address that is not added with
+// a small, configurable probability. It is meant as a pre-filter to cheaply
+// reject addresses before an authoritative (and slower) check.
+//
+// IPv4BloomFilter is safe for concurrent use.
+type IPv4BloomFilter struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	m      uint32 // The number of bits.
+	hashes uint32 // The number of hash functions.
+}
+
+// NewIPv4BloomFilter creates a Bloom filter sized to hold n addresses with a
+// false-positive rate of at most fpRate.
+func NewIPv4BloomFilter(n int, fpRate float64) *IPv4BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	// The optimal number of bits and of hash functions.
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Ceil(m / float64(n) * math.Ln2)
+	if m > math.MaxUint32 {
+		m = math.MaxUint32
+	}
+	return &IPv4BloomFilter{
+		bits:   make([]uint64, (uint64(m)+63)/64),
+		m:      uint32(m),
+		hashes: uint32(k),
+	}
+}
+
+// locations calls f for the bits of ip. The k hash functions are derived from
+// two hashes of ip with the double hashing technique of Kirsch and
+// Mitzenmacher: h_i(ip) = h1(ip) + i*h2(ip).
+func (f *IPv4BloomFilter) locations(ip IPv4Addr, fn func(bit uint32)) {
+	h1 := ip.Hash()
+	// A second, independent hash: the FNV-1a hash of the reversed address.
+	h2 := hash32([]byte{ip[3], ip[2], ip[1], ip[0]}) | 1
+	for i := uint32(0); i < f.hashes; i++ {
+		fn((h1 + i*h2) % f.m)
+	}
+}
+
+// Add adds ip to the filter.
+func (f *IPv4BloomFilter) Add(ip IPv4Addr) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.locations(ip, func(bit uint32) {
+		f.bits[bit/64] |= 1 << (bit % 64)
+	})
+}
+
+// MayContain returns false if ip is definitely not added to the filter, and
+// true if it may be.
+func (f *IPv4BloomFilter) MayContain(ip IPv4Addr) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	contains := true
+	f.locations(ip, func(bit uint32) {
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			contains = false
+		}
+	})
+	return contains
+}
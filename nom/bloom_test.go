@@ -0,0 +1,66 @@
+package nom
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestIPv4BloomFilter(t *testing.T) {
+	const (
+		n      = 10000
+		fpRate = 0.01
+	)
+	f := NewIPv4BloomFilter(n, fpRate)
+	r := rand.New(rand.NewSource(1))
+	added := make(map[IPv4Addr]bool, n)
+	for len(added) < n {
+		var ip IPv4Addr
+		ip.FromUint(r.Uint32())
+		f.Add(ip)
+		added[ip] = true
+	}
+
+	for ip := range added {
+		if !f.MayContain(ip) {
+			t.Fatalf("false negative for %v", ip)
+		}
+	}
+
+	fp, trials := 0, 100000
+	for i := 0; i < trials; {
+		var ip IPv4Addr
+		ip.FromUint(r.Uint32())
+		if added[ip] {
+			continue
+		}
+		i++
+		if f.MayContain(ip) {
+			fp++
+		}
+	}
+	// Allow some slack over the configured rate for randomness.
+	if rate := float64(fp) / float64(trials); rate > 1.5*fpRate {
+		t.Errorf("false positive rate is too high: actual=%v want<=%v", rate,
+			fpRate)
+	}
+}
+
+func TestIPv4BloomFilterSequential(t *testing.T) {
+	f := NewIPv4BloomFilter(256, 0.01)
+	base := CIDRToMaskedIPv4(0x0A000000, 24)
+	base.Hosts(func(ip IPv4Addr) bool {
+		f.Add(ip)
+		return true
+	})
+	fp := 0
+	ip := IPv4Addr{10, 0, 1, 0}
+	for i := 0; i < 10000; i++ {
+		if f.MayContain(ip) {
+			fp++
+		}
+		ip = ip.Next()
+	}
+	if fp > 150 {
+		t.Errorf("too many false positives for sequential addresses: %v", fp)
+	}
+}
@@ -0,0 +1,92 @@
+package nom
+
+// CoversAllIPv4 returns whether prefixes fully cover the address space in
+// space. If they do not, it also returns the minimal list of prefixes that
+// cover the gaps, sorted by address. Prefixes outside of space are ignored.
+//
+// This is mostly used to validate that a routing table has a route for every
+// destination in a space.
+func CoversAllIPv4(space MaskedIPv4Addr, prefixes []MaskedIPv4Addr) (bool,
+	[]MaskedIPv4Addr) {
+
+	gaps := ipv4Gaps(space.Canonicalize(), prefixes, nil)
+	return len(gaps) == 0, gaps
+}
+
+// ipv4Gaps appends the parts of space that are not covered by prefixes to
+// gaps. It recursively halves space until each half is either fully covered
+// or fully uncovered.
+func ipv4Gaps(space MaskedIPv4Addr, prefixes []MaskedIPv4Addr,
+	gaps []MaskedIPv4Addr) []MaskedIPv4Addr {
+
+	var inside []MaskedIPv4Addr
+	for _, p := range prefixes {
+		switch {
+		case p.Subsumes(space):
+			return gaps
+		case space.Subsumes(p):
+			inside = append(inside, p)
+		}
+	}
+	if len(inside) == 0 {
+		return append(gaps, space)
+	}
+	for _, half := range space.Subnets(space.PrefixLen() + 1) {
+		gaps = ipv4Gaps(half, inside, gaps)
+	}
+	return gaps
+}
+
+// CoverageFractionV4 returns the fraction of the addresses in space that are
+// covered by prefixes, between 0 and 1. Overlapping prefixes are counted
+// once, and prefixes outside of space are ignored. This is useful to monitor
+// how much of an address space is allocated.
+func CoverageFractionV4(space MaskedIPv4Addr,
+	prefixes []MaskedIPv4Addr) float64 {
+
+	var inside []MaskedIPv4Addr
+	for _, p := range prefixes {
+		switch {
+		case p.Subsumes(space):
+			return 1
+		case space.Subsumes(p):
+			inside = append(inside, p)
+		}
+	}
+	// Aggregated prefixes are disjoint.
+	var covered uint64
+	for _, p := range AggregateIPv4(inside) {
+		covered += 1 << uint(32-p.PrefixLen())
+	}
+	return float64(covered) / float64(uint64(1)<<uint(32-space.PrefixLen()))
+}
+
+// CoversAllIPv6 is the IPv6 equivalent of CoversAllIPv4.
+func CoversAllIPv6(space MaskedIPv6Addr, prefixes []MaskedIPv6Addr) (bool,
+	[]MaskedIPv6Addr) {
+
+	gaps := ipv6Gaps(space.Canonicalize(), prefixes, nil)
+	return len(gaps) == 0, gaps
+}
+
+func ipv6Gaps(space MaskedIPv6Addr, prefixes []MaskedIPv6Addr,
+	gaps []MaskedIPv6Addr) []MaskedIPv6Addr {
+
+	var inside []MaskedIPv6Addr
+	for _, p := range prefixes {
+		switch {
+		case p.Subsumes(space):
+			return gaps
+		case space.Subsumes(p):
+			inside = append(inside, p)
+		}
+	}
+	if len(inside) == 0 {
+		return append(gaps, space)
+	}
+	halves, _ := space.SubnetsN(space.PrefixLen()+1, 2)
+	for _, half := range halves {
+		gaps = ipv6Gaps(half, inside, gaps)
+	}
+	return gaps
+}
@@ -0,0 +1,87 @@
+package nom
+
+import "testing"
+
+func TestCoversAllIPv4(t *testing.T) {
+	space := CIDRToMaskedIPv4(0x0A000000, 24)
+	full := []MaskedIPv4Addr{
+		CIDRToMaskedIPv4(0x0A000000, 25),
+		CIDRToMaskedIPv4(0x0A000080, 26),
+		CIDRToMaskedIPv4(0x0A0000C0, 26),
+	}
+	if ok, gaps := CoversAllIPv4(space, full); !ok || len(gaps) != 0 {
+		t.Errorf("%v should be covered: gaps=%v", space, gaps)
+	}
+	def := []MaskedIPv4Addr{CIDRToMaskedIPv4(0, 0)}
+	if ok, _ := CoversAllIPv4(space, def); !ok {
+		t.Errorf("%v should be covered by the default route", space)
+	}
+
+	partial := []MaskedIPv4Addr{
+		CIDRToMaskedIPv4(0x0A000000, 25),
+		CIDRToMaskedIPv4(0x0A0000C0, 27),
+		CIDRToMaskedIPv4(0x0B000000, 8),
+	}
+	ok, gaps := CoversAllIPv4(space, partial)
+	want := []MaskedIPv4Addr{
+		CIDRToMaskedIPv4(0x0A000080, 26),
+		CIDRToMaskedIPv4(0x0A0000E0, 27),
+	}
+	if ok || len(gaps) != len(want) {
+		t.Fatalf("invalid gaps: actual=%v want=%v", gaps, want)
+	}
+	for i := range want {
+		if gaps[i] != want[i] {
+			t.Errorf("invalid gap: actual=%v want=%v", gaps[i], want[i])
+		}
+	}
+}
+
+func TestCoversAllIPv6(t *testing.T) {
+	space := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8},
+		Mask: MaskFromPrefixLen6(32),
+	}
+	half := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8},
+		Mask: MaskFromPrefixLen6(33),
+	}
+	ok, gaps := CoversAllIPv6(space, []MaskedIPv6Addr{half})
+	if ok || len(gaps) != 1 || gaps[0].String() != "2001:db8:8000::/33" {
+		t.Errorf("invalid gaps: actual=%v want=[2001:db8:8000::/33]", gaps)
+	}
+	if ok, _ := CoversAllIPv6(space, []MaskedIPv6Addr{space}); !ok {
+		t.Errorf("%v should cover itself", space)
+	}
+}
+
+func TestCoverageFractionV4(t *testing.T) {
+	space := CIDRToMaskedIPv4(0x0A000000, 24)
+	tests := []struct {
+		prefixes []MaskedIPv4Addr
+		want     float64
+	}{
+		{nil, 0},
+		{[]MaskedIPv4Addr{CIDRToMaskedIPv4(0x0A000000, 25)}, 0.5},
+		// Naive summation would give 0.5 + 0.25 + 0.5 = 1.25.
+		{[]MaskedIPv4Addr{
+			CIDRToMaskedIPv4(0x0A000000, 25),
+			CIDRToMaskedIPv4(0x0A000000, 26),
+			CIDRToMaskedIPv4(0x0A000000, 25),
+			CIDRToMaskedIPv4(0x0A0000C0, 26),
+			CIDRToMaskedIPv4(0x0B000000, 8),
+		}, 0.75},
+		{[]MaskedIPv4Addr{CIDRToMaskedIPv4(0x0A000000, 8)}, 1},
+	}
+	for _, tc := range tests {
+		if f := CoverageFractionV4(space, tc.prefixes); f != tc.want {
+			t.Errorf("invalid coverage of %v by %v: actual=%v want=%v", space,
+				tc.prefixes, f, tc.want)
+		}
+	}
+
+	if f := CoverageFractionV4(CIDRToMaskedIPv4(0, 0),
+		[]MaskedIPv4Addr{CIDRToMaskedIPv4(0x80000000, 1)}); f != 0.5 {
+		t.Errorf("invalid coverage of the whole space: actual=%v want=0.5", f)
+	}
+}
@@ -0,0 +1,97 @@
+package nom
+
+import (
+	"sort"
+	"sync"
+)
+
+// BroadcastDomain tracks the port on which each MAC address is observed in
+// an L2 broadcast domain, which is the data model of a learning switch.
+// Ports are identified by their UID, so a domain can span several nodes. The
+// zero value is not usable; use NewBroadcastDomain to create one.
+//
+// BroadcastDomain is safe for concurrent use.
+type BroadcastDomain struct {
+	mu     sync.RWMutex
+	byMAC  map[MACAddr]UID
+	byPort map[UID]map[MACAddr]struct{}
+}
+
+// NewBroadcastDomain creates an empty broadcast domain.
+func NewBroadcastDomain() *BroadcastDomain {
+	return &BroadcastDomain{
+		byMAC:  make(map[MACAddr]UID),
+		byPort: make(map[UID]map[MACAddr]struct{}),
+	}
+}
+
+// Observe records that a frame with the source address mac is received on
+// port. If mac was observed on another port (ie, the host has moved), it is
+// moved to port and the previous port is returned along with true.
+func (d *BroadcastDomain) Observe(mac MACAddr, port UID) (prev UID,
+	moved bool) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	prev, ok := d.byMAC[mac]
+	if ok && prev == port {
+		return prev, false
+	}
+	if ok {
+		d.unbind(mac, prev)
+	}
+	d.byMAC[mac] = port
+	macs, ok := d.byPort[port]
+	if !ok {
+		macs = make(map[MACAddr]struct{})
+		d.byPort[port] = macs
+	}
+	macs[mac] = struct{}{}
+	return prev, prev != Nil
+}
+
+func (d *BroadcastDomain) unbind(mac MACAddr, port UID) {
+	macs := d.byPort[port]
+	delete(macs, mac)
+	if len(macs) == 0 {
+		delete(d.byPort, port)
+	}
+}
+
+// Forget removes mac from the domain, eg, when its entry ages out.
+func (d *BroadcastDomain) Forget(mac MACAddr) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if port, ok := d.byMAC[mac]; ok {
+		delete(d.byMAC, mac)
+		d.unbind(mac, port)
+	}
+}
+
+// PortFor returns the port on which mac was last observed.
+func (d *BroadcastDomain) PortFor(mac MACAddr) (UID, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	port, ok := d.byMAC[mac]
+	return port, ok
+}
+
+// MACsOnPort returns the MAC addresses last observed on port in ascending
+// order.
+func (d *BroadcastDomain) MACsOnPort(port UID) []MACAddr {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	macs := make([]MACAddr, 0, len(d.byPort[port]))
+	for mac := range d.byPort[port] {
+		macs = append(macs, mac)
+	}
+	sort.Sort(macAddrs(macs))
+	return macs
+}
+
+type macAddrs []MACAddr
+
+func (a macAddrs) Len() int           { return len(a) }
+func (a macAddrs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a macAddrs) Less(i, j int) bool { return a[i].Less(a[j]) }
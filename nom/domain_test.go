@@ -0,0 +1,73 @@
+package nom
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBroadcastDomainMove(t *testing.T) {
+	d := NewBroadcastDomain()
+	m1 := MACAddr{0x02, 0, 0, 0, 0, 1}
+	m2 := MACAddr{0x02, 0, 0, 0, 0, 2}
+	p1 := UIDJoin("net", "n1", "1")
+	p2 := UIDJoin("net", "n1", "2")
+
+	if _, moved := d.Observe(m2, p1); moved {
+		t.Errorf("%v moved on its first observation", m2)
+	}
+	d.Observe(m1, p1)
+	if _, moved := d.Observe(m1, p1); moved {
+		t.Errorf("%v moved when observed on the same port", m1)
+	}
+	if macs := d.MACsOnPort(p1); !reflect.DeepEqual(macs, []MACAddr{m1, m2}) {
+		t.Errorf("invalid MACs on %v: %v", p1, macs)
+	}
+
+	prev, moved := d.Observe(m1, p2)
+	if !moved || prev != p1 {
+		t.Errorf("invalid move of %v: actual=%v,%v want=%v,true", m1, prev, moved,
+			p1)
+	}
+	if port, ok := d.PortFor(m1); !ok || port != p2 {
+		t.Errorf("invalid port for %v: actual=%v want=%v", m1, port, p2)
+	}
+	if macs := d.MACsOnPort(p1); !reflect.DeepEqual(macs, []MACAddr{m2}) {
+		t.Errorf("invalid MACs on %v after the move: %v", p1, macs)
+	}
+
+	d.Forget(m2)
+	if macs := d.MACsOnPort(p1); len(macs) != 0 {
+		t.Errorf("invalid MACs on %v after forgetting %v: %v", p1, m2, macs)
+	}
+	if _, ok := d.PortFor(m2); ok {
+		t.Errorf("%v is not forgotten", m2)
+	}
+}
+
+func TestBroadcastDomainConcurrent(t *testing.T) {
+	d := NewBroadcastDomain()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			port := UIDJoin("net", "n1", string(rune('0'+i)))
+			for j := 0; j < 100; j++ {
+				mac := MACAddr{0x02, 0, 0, 0, 0, byte(j)}
+				d.Observe(mac, port)
+				d.PortFor(mac)
+				d.MACsOnPort(port)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	n := 0
+	for i := 0; i < 8; i++ {
+		n += len(d.MACsOnPort(UIDJoin("net", "n1", string(rune('0'+i)))))
+	}
+	if n != 100 {
+		t.Errorf("invalid number of MACs: actual=%v want=100", n)
+	}
+}
@@ -0,0 +1,51 @@
+package nom
+
+import "math"
+
+// shannon returns the Shannon entropy, in bits, of a distribution given as the
+// number of occurrences of each symbol out of total.
+func shannon(counts map[uint64]int, total int) float64 {
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// IPv4Entropy returns the Shannon entropy of addrs in bits, computed as the sum
+// of the entropies of each of the four octets. It ranges from 0, when all the
+// addresses are equal, to 32, when every octet is uniformly distributed.
+// Addresses clustered in a few subnets have a low entropy in their leading
+// octets, whereas a scan or a flood with spoofed sources has a high entropy.
+//
+// The result only depends on the multiset of addrs, not on their order. It
+// returns 0 for an empty slice.
+func IPv4Entropy(addrs []IPv4Addr) float64 {
+	if len(addrs) == 0 {
+		return 0
+	}
+	var h float64
+	for i := 0; i < IPv4Len; i++ {
+		counts := make(map[uint64]int)
+		for _, ip := range addrs {
+			counts[uint64(ip[i])]++
+		}
+		h += shannon(counts, len(addrs))
+	}
+	return h
+}
+
+// MACOUIEntropy returns the Shannon entropy of the OUIs of macs in bits. The
+// OUI identifies the vendor, so a sudden rise in entropy suggests spoofed
+// source MAC addresses. Like IPv4Entropy, it returns 0 for an empty slice.
+func MACOUIEntropy(macs []MACAddr) float64 {
+	if len(macs) == 0 {
+		return 0
+	}
+	counts := make(map[uint64]int)
+	for _, m := range macs {
+		counts[m.Anonymize().Uint64()]++
+	}
+	return shannon(counts, len(macs))
+}
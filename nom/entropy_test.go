@@ -0,0 +1,39 @@
+package nom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIPv4Entropy(t *testing.T) {
+	var uniform, clustered []IPv4Addr
+	for i := 0; i < 256; i++ {
+		b := byte(i)
+		uniform = append(uniform, IPv4Addr{b, b, b, b})
+		clustered = append(clustered, IPv4Addr{10, 0, 0, b})
+	}
+	if h := IPv4Entropy(uniform); math.Abs(h-32) > 1e-9 {
+		t.Errorf("invalid entropy of uniform addresses: actual=%v want=32", h)
+	}
+	if h := IPv4Entropy(clustered); math.Abs(h-8) > 1e-9 {
+		t.Errorf("invalid entropy of clustered addresses: actual=%v want=8", h)
+	}
+	if h := IPv4Entropy(nil); h != 0 {
+		t.Errorf("invalid entropy of no addresses: actual=%v want=0", h)
+	}
+}
+
+func TestMACOUIEntropy(t *testing.T) {
+	var uniform, clustered []MACAddr
+	for i := 0; i < 16; i++ {
+		b := byte(i)
+		uniform = append(uniform, MACAddr{0x00, 0x11, b, 0, 0, 1})
+		clustered = append(clustered, MACAddr{0x00, 0x11, 0x22, 0, 0, b})
+	}
+	if h := MACOUIEntropy(uniform); math.Abs(h-4) > 1e-9 {
+		t.Errorf("invalid entropy of uniform OUIs: actual=%v want=4", h)
+	}
+	if h := MACOUIEntropy(clustered); h != 0 {
+		t.Errorf("invalid entropy of a single OUI: actual=%v want=0", h)
+	}
+}
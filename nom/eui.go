@@ -0,0 +1,19 @@
+package nom
+
+// ToEUI64NoFlip returns the EUI-64 identifier of the MAC address by inserting
+// ff:fe between its OUI and its NIC-specific bytes; eg, 00:11:22:33:44:55
+// becomes 00:11:22:ff:fe:33:44:55. Unlike the modified EUI-64 used for SLAAC
+// interface identifiers (RFC 4291), the universal/local bit is kept as is.
+func (m MACAddr) ToEUI64NoFlip() [8]byte {
+	return [8]byte{m[0], m[1], m[2], 0xFF, 0xFE, m[3], m[4], m[5]}
+}
+
+// MACFromEUI64 returns the MAC address encoded in an EUI-64 identifier by
+// ToEUI64NoFlip. It returns false if the identifier was not derived from a MAC
+// address; ie, if its middle bytes are not ff:fe.
+func MACFromEUI64(eui [8]byte) (MACAddr, bool) {
+	if eui[3] != 0xFF || eui[4] != 0xFE {
+		return MACAddr{}, false
+	}
+	return MACAddr{eui[0], eui[1], eui[2], eui[5], eui[6], eui[7]}, true
+}
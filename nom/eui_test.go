@@ -0,0 +1,23 @@
+package nom
+
+import "testing"
+
+func TestEUI64NoFlip(t *testing.T) {
+	mac := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	eui := mac.ToEUI64NoFlip()
+	want := [8]byte{0x00, 0x11, 0x22, 0xFF, 0xFE, 0x33, 0x44, 0x55}
+	if eui != want {
+		t.Errorf("invalid EUI-64 for %v: actual=%x want=%x", mac, eui, want)
+	}
+
+	back, ok := MACFromEUI64(eui)
+	if !ok || back != mac {
+		t.Errorf("invalid MAC from %x: actual=%v,%v want=%v,true", eui, back, ok,
+			mac)
+	}
+
+	eui[4] = 0xFF
+	if back, ok := MACFromEUI64(eui); ok {
+		t.Errorf("extracted %v from %x without ff:fe", back, eui)
+	}
+}
@@ -0,0 +1,34 @@
+package nom
+
+import "fmt"
+
+// Explain returns a multi-line, human-readable explanation of whether ip
+// matches mi, showing the rule, the mask, and the masked values that are
+// compared. It is meant for debugging; use String for a terse form.
+func (mi MaskedIPv4Addr) Explain(ip IPv4Addr) string {
+	return explainMatch(mi.Addr, mi.Mask, ip, mi.Network(), ip.Mask(mi.Mask),
+		mi.Match(ip))
+}
+
+// Explain is the IPv6 equivalent of MaskedIPv4Addr.Explain.
+func (mi MaskedIPv6Addr) Explain(ip IPv6Addr) string {
+	return explainMatch(mi.Addr, mi.Mask, ip, mi.Network(), ip.Mask(mi.Mask),
+		mi.Match(ip))
+}
+
+// Explain is the MAC equivalent of MaskedIPv4Addr.Explain.
+func (mm MaskedMACAddr) Explain(mac MACAddr) string {
+	return explainMatch(mm.Addr, mm.Mask, mac, mm.Network(), mac.Mask(mm.Mask),
+		mm.Match(mac))
+}
+
+func explainMatch(rule, mask, addr, maskedRule, maskedAddr fmt.Stringer,
+	match bool) string {
+
+	return fmt.Sprintf("rule:           %v\n"+
+		"mask:           %v\n"+
+		"address:        %v\n"+
+		"rule & mask:    %v\n"+
+		"address & mask: %v\n"+
+		"match:          %v\n", rule, mask, addr, maskedRule, maskedAddr, match)
+}
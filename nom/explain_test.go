@@ -0,0 +1,27 @@
+package nom
+
+import "testing"
+
+func TestExplain(t *testing.T) {
+	p := CIDRToMaskedIPv4(0x0A000100, 24)
+	want := "rule:           10.0.1.0\n" +
+		"mask:           255.255.255.0\n" +
+		"address:        10.0.0.1\n" +
+		"rule & mask:    10.0.1.0\n" +
+		"address & mask: 10.0.0.0\n" +
+		"match:          false\n"
+	if e := p.Explain(IPv4Addr{10, 0, 0, 1}); e != want {
+		t.Errorf("invalid explanation: actual=\n%v\nwant=\n%v", e, want)
+	}
+
+	m := MaskedMACAddr{Addr: MACAddr{0x01}, Mask: MACAddr{0x01}}
+	want = "rule:           01:00:00:00:00:00\n" +
+		"mask:           01:00:00:00:00:00\n" +
+		"address:        33:33:00:00:00:01\n" +
+		"rule & mask:    01:00:00:00:00:00\n" +
+		"address & mask: 01:00:00:00:00:00\n" +
+		"match:          true\n"
+	if e := m.Explain(MACAddr{0x33, 0x33, 0, 0, 0, 1}); e != want {
+		t.Errorf("invalid explanation: actual=\n%v\nwant=\n%v", e, want)
+	}
+}
@@ -0,0 +1,50 @@
+package nom
+
+// FloodBehavior is the forwarding behavior required for a frame based on its
+// destination MAC address.
+type FloodBehavior uint8
+
+// Valid values for FloodBehavior.
+const (
+	// FloodUnicast means that the frame should be forwarded to the port on
+	// which the destination is learned.
+	FloodUnicast FloodBehavior = iota
+	// FloodAll means that the frame is a broadcast and should be flooded on all
+	// ports.
+	FloodAll
+	// FloodMulticastGroup means that the frame should be forwarded to the
+	// members of its multicast group (or flooded if membership is not known).
+	FloodMulticastGroup
+	// FloodNone means that the frame is a link-local control frame (e.g., LLDP)
+	// that must never be forwarded.
+	FloodNone
+)
+
+func (b FloodBehavior) String() string {
+	switch b {
+	case FloodUnicast:
+		return "unicast"
+	case FloodAll:
+		return "flood-all"
+	case FloodMulticastGroup:
+		return "multicast-group"
+	case FloodNone:
+		return "none"
+	}
+	return "unknown"
+}
+
+// FloodTargets classifies how a frame destined to dst should be forwarded.
+// LLDP destinations are checked first since they are also multicast
+// addresses.
+func FloodTargets(dst MACAddr) FloodBehavior {
+	switch {
+	case dst.IsLLDP():
+		return FloodNone
+	case dst.IsBroadcast():
+		return FloodAll
+	case dst.IsMulticast():
+		return FloodMulticastGroup
+	}
+	return FloodUnicast
+}
@@ -0,0 +1,24 @@
+package nom
+
+import "testing"
+
+func TestFloodTargets(t *testing.T) {
+	macs := map[MACAddr]FloodBehavior{
+		BroadcastMAC:         FloodAll,
+		CDPMulticastMAC:      FloodMulticastGroup,
+		CiscoSTPMulticastMAC: FloodMulticastGroup,
+		LLDPMulticastMACs[0]: FloodNone,
+		LLDPMulticastMACs[1]: FloodNone,
+		LLDPMulticastMACs[2]: FloodNone,
+
+		MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}: FloodUnicast,
+		MACAddr{0x01, 0x00, 0x5E, 0x00, 0x00, 0x05}: FloodMulticastGroup,
+		MACAddr{0x33, 0x33, 0x00, 0x00, 0x00, 0x01}: FloodMulticastGroup,
+	}
+	for m, b := range macs {
+		if FloodTargets(m) != b {
+			t.Errorf("invalid flood behavior for %v: actual=%v want=%v", m,
+				FloodTargets(m), b)
+		}
+	}
+}
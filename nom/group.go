@@ -0,0 +1,40 @@
+package nom
+
+// GroupIPv4By buckets addrs by the key returned by key for each address; eg,
+// by their /24 network or by the site they belong to. Addresses keep their
+// relative order within each bucket.
+func GroupIPv4By(addrs []IPv4Addr,
+	key func(ip IPv4Addr) string) map[string][]IPv4Addr {
+
+	groups := make(map[string][]IPv4Addr)
+	for _, ip := range addrs {
+		k := key(ip)
+		groups[k] = append(groups[k], ip)
+	}
+	return groups
+}
+
+// GroupIPv6By is the IPv6 variant of GroupIPv4By.
+func GroupIPv6By(addrs []IPv6Addr,
+	key func(ip IPv6Addr) string) map[string][]IPv6Addr {
+
+	groups := make(map[string][]IPv6Addr)
+	for _, ip := range addrs {
+		k := key(ip)
+		groups[k] = append(groups[k], ip)
+	}
+	return groups
+}
+
+// GroupMACBy is the MAC variant of GroupIPv4By. For example, use
+// MACAddr.Anonymize to group addresses by their OUI.
+func GroupMACBy(macs []MACAddr,
+	key func(mac MACAddr) string) map[string][]MACAddr {
+
+	groups := make(map[string][]MACAddr)
+	for _, mac := range macs {
+		k := key(mac)
+		groups[k] = append(groups[k], mac)
+	}
+	return groups
+}
@@ -0,0 +1,33 @@
+package nom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupIPv4By(t *testing.T) {
+	addrs := []IPv4Addr{{10, 0, 0, 1}, {10, 0, 1, 1}, {10, 0, 0, 2},
+		{192, 168, 0, 1}}
+	groups := GroupIPv4By(addrs, func(ip IPv4Addr) string {
+		return ip.Anonymize(24).String()
+	})
+	want := map[string][]IPv4Addr{
+		"10.0.0.0":    {{10, 0, 0, 1}, {10, 0, 0, 2}},
+		"10.0.1.0":    {{10, 0, 1, 1}},
+		"192.168.0.0": {{192, 168, 0, 1}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("invalid groups: actual=%v want=%v", groups, want)
+	}
+}
+
+func TestGroupMACBy(t *testing.T) {
+	macs := []MACAddr{{0x00, 0x11, 0x22, 0, 0, 1}, {0x00, 0x33, 0x44, 0, 0, 1},
+		{0x00, 0x11, 0x22, 0, 0, 2}}
+	groups := GroupMACBy(macs, func(mac MACAddr) string {
+		return mac.Anonymize().String()
+	})
+	if n := len(groups["00:11:22:00:00:00"]); n != 2 || len(groups) != 2 {
+		t.Errorf("invalid groups by OUI: %v", groups)
+	}
+}
@@ -0,0 +1,114 @@
+package nom
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// hashRingReplicas is the number of points of each node on a HashRing. More
+// points balance the keys better at the cost of memory.
+const hashRingReplicas = 64
+
+// hashRingPoint is a point on a HashRing owned by node.
+type hashRingPoint struct {
+	hash uint32
+	node string
+}
+
+type hashRingPoints []hashRingPoint
+
+func (p hashRingPoints) Len() int      { return len(p) }
+func (p hashRingPoints) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p hashRingPoints) Less(i, j int) bool {
+	if p[i].hash != p[j].hash {
+		return p[i].hash < p[j].hash
+	}
+	return p[i].node < p[j].node
+}
+
+// HashRing assigns addresses to nodes (eg, the bees of a controller) using
+// consistent hashing: an address is mapped to the first node point that
+// follows the hash of the address on the ring. When a node is added or
+// removed, only the addresses of that node move. The hash of an address is
+// the same as its Hash method. The zero value is an empty ring ready to use.
+//
+// HashRing is safe for concurrent use.
+type HashRing struct {
+	mu     sync.RWMutex
+	points []hashRingPoint
+	nodes  map[string]struct{}
+}
+
+// NewHashRing creates a ring with the given nodes.
+func NewHashRing(nodes ...string) *HashRing {
+	r := &HashRing{}
+	for _, n := range nodes {
+		r.AddNode(n)
+	}
+	return r
+}
+
+// AddNode adds node to the ring. It is a no-op if node is already on the ring.
+func (r *HashRing) AddNode(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.nodes[node]; ok {
+		return
+	}
+	if r.nodes == nil {
+		r.nodes = make(map[string]struct{})
+	}
+	r.nodes[node] = struct{}{}
+	for i := 0; i < hashRingReplicas; i++ {
+		h := hash32([]byte(node + "#" + strconv.Itoa(i)))
+		r.points = append(r.points, hashRingPoint{hash: h, node: node})
+	}
+	sort.Sort(hashRingPoints(r.points))
+}
+
+// RemoveNode removes node from the ring, and returns whether it was present.
+func (r *HashRing) RemoveNode(node string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.nodes[node]; !ok {
+		return false
+	}
+	delete(r.nodes, node)
+	points := r.points[:0]
+	for _, p := range r.points {
+		if p.node != node {
+			points = append(points, p)
+		}
+	}
+	r.points = points
+	return true
+}
+
+// Len returns the number of nodes on the ring.
+func (r *HashRing) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.nodes)
+}
+
+// NodeFor returns the node that owns a. It returns an empty string if the
+// ring has no nodes.
+func (r *HashRing) NodeFor(a Addr) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hash32([]byte(a.Key()))
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= h
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].node
+}
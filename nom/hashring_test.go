@@ -0,0 +1,58 @@
+package nom
+
+import "testing"
+
+func TestHashRingRemoveNode(t *testing.T) {
+	r := NewHashRing("bee1", "bee2", "bee3", "bee4")
+	const n = 4096
+	before := make(map[IPv4Addr]string, n)
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		var ip IPv4Addr
+		ip.FromUint(0x0A000000 + uint32(i))
+		node := r.NodeFor(ip)
+		before[ip] = node
+		counts[node]++
+	}
+	for _, node := range []string{"bee1", "bee2", "bee3", "bee4"} {
+		if counts[node] < n/8 {
+			t.Errorf("%v owns too few addresses: %v of %v", node, counts[node], n)
+		}
+	}
+
+	if !r.RemoveNode("bee2") || r.RemoveNode("bee2") {
+		t.Fatalf("cannot remove bee2 exactly once")
+	}
+	moved := 0
+	for ip, prev := range before {
+		node := r.NodeFor(ip)
+		if node == "bee2" {
+			t.Fatalf("%v is still assigned to the removed node", ip)
+		}
+		if node != prev {
+			moved++
+			if prev != "bee2" {
+				t.Errorf("%v moved from %v to %v", ip, prev, node)
+			}
+		}
+	}
+	if moved != counts["bee2"] {
+		t.Errorf("invalid number of moved addresses: actual=%v want=%v", moved,
+			counts["bee2"])
+	}
+}
+
+func TestHashRingEmpty(t *testing.T) {
+	var r HashRing
+	if node := r.NodeFor(MACAddr{}); node != "" {
+		t.Errorf("empty ring assigned an address to %q", node)
+	}
+	r.AddNode("bee1")
+	r.AddNode("bee1")
+	if r.Len() != 1 {
+		t.Errorf("invalid number of nodes: actual=%v want=1", r.Len())
+	}
+	if node := r.NodeFor(MACAddr{}); node != "bee1" {
+		t.Errorf("invalid node: actual=%v want=bee1", node)
+	}
+}
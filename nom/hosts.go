@@ -0,0 +1,226 @@
+package nom
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+// hostRange returns the first and the last usable host addresses of the
+// prefix. The network and broadcast addresses are excluded, except for /31
+// and /32 prefixes where every address is usable.
+func (mi MaskedIPv4Addr) hostRange() (first, last uint32) {
+	mask := mi.Mask.Uint32()
+	first = mi.Addr.Uint32() & mask
+	last = first | ^mask
+	if mi.PrefixLen() < 31 {
+		first++
+		last--
+	}
+	return first, last
+}
+
+// Broadcast returns the broadcast address of the prefix; ie, Addr with all
+// of its host bits set.
+func (mi MaskedIPv4Addr) Broadcast() IPv4Addr {
+	var b IPv4Addr
+	b.FromUint(mi.Addr.Uint32() | ^mi.Mask.Uint32())
+	return b
+}
+
+// FirstHost returns the first usable host address of the prefix. See Hosts.
+func (mi MaskedIPv4Addr) FirstHost() IPv4Addr {
+	first, _ := mi.hostRange()
+	var ip IPv4Addr
+	ip.FromUint(first)
+	return ip
+}
+
+// LastHost returns the last usable host address of the prefix. See Hosts.
+func (mi MaskedIPv4Addr) LastHost() IPv4Addr {
+	_, last := mi.hostRange()
+	var ip IPv4Addr
+	ip.FromUint(last)
+	return ip
+}
+
+// GatewayFirst returns the gateway address of the prefix by the common
+// convention of using its first usable host; eg, 10.0.0.1 for 10.0.0.0/24.
+// It is the same as FirstHost.
+func (mi MaskedIPv4Addr) GatewayFirst() IPv4Addr {
+	return mi.FirstHost()
+}
+
+// GatewayLast returns the gateway address of the prefix by the convention of
+// using its last usable host; eg, 10.0.0.254 for 10.0.0.0/24. It is the same
+// as LastHost.
+func (mi MaskedIPv4Addr) GatewayLast() IPv4Addr {
+	return mi.LastHost()
+}
+
+// NumHosts returns the number of usable host addresses in the prefix; eg, 254
+// for a /24, 2 for a /31, and 1 for a /32. See Hosts.
+func (mi MaskedIPv4Addr) NumHosts() uint64 {
+	first, last := mi.hostRange()
+	return uint64(last-first) + 1
+}
+
+// PeerOf returns the address at the other end of a point-to-point link
+// numbered from the prefix; ie, the other address of a /31, or the other
+// usable host of a /30. It returns false if the prefix is neither a /31 nor
+// a /30, or if ip is not a usable host of the prefix.
+func (mi MaskedIPv4Addr) PeerOf(ip IPv4Addr) (IPv4Addr, bool) {
+	if !IsValidNetmask4(mi.Mask) || !IsUsableHostV4(ip, mi) {
+		return IPv4Addr{}, false
+	}
+
+	var peer IPv4Addr
+	switch mi.PrefixLen() {
+	case 31:
+		peer.FromUint(ip.Uint32() ^ 1)
+	case 30:
+		// The usable hosts are the ones ending in 01 and 10.
+		peer.FromUint(ip.Uint32() ^ 3)
+	default:
+		return IPv4Addr{}, false
+	}
+	return peer, true
+}
+
+// IsUsableHostV4 returns whether ip is a usable host address in prefix; ie,
+// whether it is in prefix and is neither its network nor its broadcast
+// address. Every address of /31 and /32 prefixes is usable. See Hosts.
+func IsUsableHostV4(ip IPv4Addr, prefix MaskedIPv4Addr) bool {
+	first, last := prefix.hostRange()
+	a := ip.Uint32()
+	return first <= a && a <= last
+}
+
+// Hosts calls f for each usable host address of the prefix in ascending
+// order, until f returns false. The network and broadcast addresses are
+// skipped, except for /31 (RFC 3021) and /32 prefixes where every address is
+// usable. The mask is assumed to be contiguous.
+func (mi MaskedIPv4Addr) Hosts(f func(ip IPv4Addr) bool) {
+	first, last := mi.hostRange()
+	var ip IPv4Addr
+	for a := first; ; a++ {
+		ip.FromUint(a)
+		if !f(ip) || a == last {
+			return
+		}
+	}
+}
+
+// HostsReverse is like Hosts but iterates from the last usable host address
+// downward. This is useful for allocators that hand out high addresses first,
+// to avoid colliding with low, statically assigned ones.
+func (mi MaskedIPv4Addr) HostsReverse(f func(ip IPv4Addr) bool) {
+	first, last := mi.hostRange()
+	var ip IPv4Addr
+	for a := last; ; a-- {
+		ip.FromUint(a)
+		if !f(ip) || a == first {
+			return
+		}
+	}
+}
+
+// hostRange returns the first and the last usable host addresses of the
+// prefix as 128-bit integers. The network address, which is the
+// subnet-router anycast address, is excluded except for /127 (RFC 6164) and
+// /128 prefixes. IPv6 has no broadcast address.
+func (mi MaskedIPv6Addr) hostRange() (fhi, flo, lhi, llo uint64) {
+	hi, lo := mi.Addr.Uint64s()
+	mhi, mlo := mi.Mask.Uint64s()
+	fhi, flo = hi&mhi, lo&mlo
+	lhi, llo = fhi|^mhi, flo|^mlo
+	if mi.PrefixLen() < 127 {
+		fhi, flo = addUint128(fhi, flo, 0)
+	}
+	return fhi, flo, lhi, llo
+}
+
+// FirstHost returns the first usable host address of the prefix. See Hosts.
+func (mi MaskedIPv6Addr) FirstHost() IPv6Addr {
+	hi, lo, _, _ := mi.hostRange()
+	var ip IPv6Addr
+	ip.FromUint64s(hi, lo)
+	return ip
+}
+
+// LastHost returns the last host address of the prefix. See Hosts.
+func (mi MaskedIPv6Addr) LastHost() IPv6Addr {
+	_, _, hi, lo := mi.hostRange()
+	var ip IPv6Addr
+	ip.FromUint64s(hi, lo)
+	return ip
+}
+
+// NumHosts returns the number of usable host addresses in the prefix as a
+// big.Int, since it does not fit in 64 bits for prefixes shorter than /64.
+// See Hosts.
+func (mi MaskedIPv6Addr) NumHosts() *big.Int {
+	n, _ := IPv6Count(mi.FirstHost(), mi.LastHost())
+	return n
+}
+
+// Hosts calls f for each usable host address of the prefix in ascending
+// order, until f returns false. The subnet-router anycast address (ie, the
+// network address) is skipped, except for /127 and /128 prefixes. Note that
+// most IPv6 prefixes have far too many hosts to iterate over exhaustively.
+func (mi MaskedIPv6Addr) Hosts(f func(ip IPv6Addr) bool) {
+	hi, lo, lhi, llo := mi.hostRange()
+	var ip IPv6Addr
+	for {
+		ip.FromUint64s(hi, lo)
+		if !f(ip) || (hi == lhi && lo == llo) {
+			return
+		}
+		hi, lo = addUint128(hi, lo, 0)
+	}
+}
+
+// HostsReverse is like Hosts but iterates from the last host address of the
+// prefix downward.
+func (mi MaskedIPv6Addr) HostsReverse(f func(ip IPv6Addr) bool) {
+	fhi, flo, hi, lo := mi.hostRange()
+	var ip IPv6Addr
+	for {
+		ip.FromUint64s(hi, lo)
+		if !f(ip) || (hi == fhi && lo == flo) {
+			return
+		}
+		if lo == 0 {
+			hi--
+		}
+		lo--
+	}
+}
+
+// SmallestPrefixForHostsV4 returns the longest prefix length whose prefixes
+// have at least n usable hosts, which is the inverse of NumHosts; eg, 24 for
+// 254 hosts and 23 for 255 hosts. It returns an error if no IPv4 prefix has n
+// usable hosts.
+func SmallestPrefixForHostsV4(n uint64) (int, error) {
+	for l := 32; l >= 0; l-- {
+		if CIDRToMaskedIPv4(0, uint(l)).NumHosts() >= n {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("no IPv4 prefix has %d usable hosts", n)
+}
+
+// SmallestPrefixForHostsV6 is the IPv6 variant of SmallestPrefixForHostsV4.
+// Since a /64 has 2^64-1 usable hosts, the largest uint64, there is always
+// such a prefix.
+func SmallestPrefixForHostsV6(n uint64) int {
+	switch {
+	case n <= 1:
+		return 128
+	case n == 2:
+		return 127
+	}
+	// A /l prefix has 2^(128-l)-1 usable hosts for l < 127, which is at least
+	// n iff 128-l is at least the bit length of n.
+	return 128 - bits.Len64(n)
+}
@@ -0,0 +1,230 @@
+package nom
+
+import "testing"
+
+func collectIPv4Hosts(mi MaskedIPv4Addr, reverse bool) []IPv4Addr {
+	var ips []IPv4Addr
+	f := func(ip IPv4Addr) bool {
+		ips = append(ips, ip)
+		return true
+	}
+	if reverse {
+		mi.HostsReverse(f)
+	} else {
+		mi.Hosts(f)
+	}
+	return ips
+}
+
+func TestHostsIPv4(t *testing.T) {
+	for l := 0; l <= 32; l++ {
+		if l < 22 && l != 0 {
+			continue
+		}
+		mi := CIDRToMaskedIPv4(0x0A0000FF, uint(l))
+		if l == 0 {
+			// Only check the edges of 0.0.0.0/0.
+			var first, last IPv4Addr
+			mi.Hosts(func(ip IPv4Addr) bool { first = ip; return false })
+			mi.HostsReverse(func(ip IPv4Addr) bool { last = ip; return false })
+			if first != (IPv4Addr{0, 0, 0, 1}) ||
+				last != (IPv4Addr{255, 255, 255, 254}) {
+				t.Errorf("invalid hosts of %v: first=%v last=%v", mi, first, last)
+			}
+			continue
+		}
+
+		fwd := collectIPv4Hosts(mi, false)
+		rev := collectIPv4Hosts(mi, true)
+		want := 1 << uint(32-l)
+		if l < 31 {
+			want -= 2
+		}
+		if len(fwd) != want || len(rev) != want {
+			t.Errorf("invalid number of hosts in %v: forward=%v reverse=%v want=%v",
+				mi, len(fwd), len(rev), want)
+			continue
+		}
+		for i := range fwd {
+			if fwd[i] != rev[len(rev)-1-i] {
+				t.Errorf("reverse hosts of %v do not match forward hosts", mi)
+				break
+			}
+		}
+	}
+
+	rev := collectIPv4Hosts(CIDRToMaskedIPv4(0x0A000000, 24), true)
+	if rev[0] != (IPv4Addr{10, 0, 0, 254}) {
+		t.Errorf("invalid last host: actual=%v want=10.0.0.254", rev[0])
+	}
+	rev = collectIPv4Hosts(CIDRToMaskedIPv4(0x0A000000, 31), true)
+	if rev[0] != (IPv4Addr{10, 0, 0, 1}) || rev[1] != (IPv4Addr{10, 0, 0, 0}) {
+		t.Errorf("invalid hosts of a /31: %v", rev)
+	}
+}
+
+func TestHostsIPv6(t *testing.T) {
+	for _, tc := range []struct {
+		prefix string
+		want   []string
+	}{
+		{"2001:db8::/126", []string{"2001:db8::3", "2001:db8::2", "2001:db8::1"}},
+		{"2001:db8::/127", []string{"2001:db8::1", "2001:db8::"}},
+		{"2001:db8::5/128", []string{"2001:db8::5"}},
+	} {
+		mi, _ := ParseCIDRv6(tc.prefix)
+		var fwd, rev []string
+		mi.Hosts(func(ip IPv6Addr) bool {
+			fwd = append(fwd, ip.String())
+			return true
+		})
+		mi.HostsReverse(func(ip IPv6Addr) bool {
+			rev = append(rev, ip.String())
+			return true
+		})
+		if len(rev) != len(tc.want) || len(fwd) != len(tc.want) {
+			t.Errorf("invalid hosts of %v: forward=%v reverse=%v want=%v",
+				tc.prefix, fwd, rev, tc.want)
+			continue
+		}
+		for i := range tc.want {
+			if rev[i] != tc.want[i] || fwd[len(fwd)-1-i] != tc.want[i] {
+				t.Errorf("invalid hosts of %v: forward=%v reverse=%v want=%v",
+					tc.prefix, fwd, rev, tc.want)
+				break
+			}
+		}
+	}
+
+	mi, _ := ParseCIDRv6("2001:db8:0:1::/64")
+	var last IPv6Addr
+	mi.HostsReverse(func(ip IPv6Addr) bool {
+		last = ip
+		return false
+	})
+	if last.String() != "2001:db8:0:1:ffff:ffff:ffff:ffff" {
+		t.Errorf("invalid last host of %v: %v", mi, last)
+	}
+}
+
+func TestIsUsableHostV4(t *testing.T) {
+	tests := []struct {
+		ip     IPv4Addr
+		prefix string
+		want   bool
+	}{
+		{IPv4Addr{10, 0, 0, 0}, "10.0.0.0/24", false},
+		{IPv4Addr{10, 0, 0, 255}, "10.0.0.0/24", false},
+		{IPv4Addr{10, 0, 0, 1}, "10.0.0.0/24", true},
+		{IPv4Addr{10, 0, 0, 77}, "10.0.0.0/24", true},
+		{IPv4Addr{10, 0, 1, 1}, "10.0.0.0/24", false},
+		{IPv4Addr{10, 0, 0, 0}, "10.0.0.0/31", true},
+		{IPv4Addr{10, 0, 0, 1}, "10.0.0.0/31", true},
+		{IPv4Addr{10, 0, 0, 1}, "10.0.0.1/32", true},
+	}
+	for _, tc := range tests {
+		p, _ := ParseCIDRv4(tc.prefix)
+		if u := IsUsableHostV4(tc.ip, p); u != tc.want {
+			t.Errorf("invalid usability of %v in %v: actual=%v want=%v", tc.ip,
+				tc.prefix, u, tc.want)
+		}
+	}
+}
+
+func TestPeerOf(t *testing.T) {
+	tests := []struct {
+		prefix string
+		ip     IPv4Addr
+		peer   IPv4Addr
+		ok     bool
+	}{
+		{"10.0.0.0/31", IPv4Addr{10, 0, 0, 0}, IPv4Addr{10, 0, 0, 1}, true},
+		{"10.0.0.0/31", IPv4Addr{10, 0, 0, 1}, IPv4Addr{10, 0, 0, 0}, true},
+		{"10.0.0.4/30", IPv4Addr{10, 0, 0, 5}, IPv4Addr{10, 0, 0, 6}, true},
+		{"10.0.0.4/30", IPv4Addr{10, 0, 0, 6}, IPv4Addr{10, 0, 0, 5}, true},
+		{"10.0.0.4/30", IPv4Addr{10, 0, 0, 4}, IPv4Addr{}, false},
+		{"10.0.0.4/30", IPv4Addr{10, 0, 0, 7}, IPv4Addr{}, false},
+		{"10.0.0.0/31", IPv4Addr{10, 0, 0, 2}, IPv4Addr{}, false},
+		{"10.0.0.0/29", IPv4Addr{10, 0, 0, 1}, IPv4Addr{}, false},
+		{"10.0.0.1/32", IPv4Addr{10, 0, 0, 1}, IPv4Addr{}, false},
+	}
+	for _, tc := range tests {
+		p, _ := ParseCIDRv4(tc.prefix)
+		peer, ok := p.PeerOf(tc.ip)
+		if ok != tc.ok || peer != tc.peer {
+			t.Errorf("invalid peer of %v in %v: actual=%v,%v want=%v,%v", tc.ip,
+				tc.prefix, peer, ok, tc.peer, tc.ok)
+		}
+	}
+}
+
+func TestSmallestPrefixForHosts(t *testing.T) {
+	tests4 := map[uint64]int{
+		0:         32,
+		1:         32,
+		2:         31,
+		3:         29,
+		6:         29,
+		7:         28,
+		254:       24,
+		255:       23,
+		1<<32 - 2: 0,
+		1<<31 - 2: 1,
+		1<<31 - 1: 0,
+		1<<16 - 2: 16,
+		1 << 16:   15,
+	}
+	for n, want := range tests4 {
+		l, err := SmallestPrefixForHostsV4(n)
+		if err != nil || l != want {
+			t.Errorf("invalid prefix for %d hosts: actual=%v want=%v (%v)", n, l,
+				want, err)
+		}
+		if err == nil && CIDRToMaskedIPv4(0, uint(l)).NumHosts() < n {
+			t.Errorf("/%d has fewer than %d hosts", l, n)
+		}
+	}
+	if _, err := SmallestPrefixForHostsV4(1<<32 - 1); err == nil {
+		t.Errorf("no error for more hosts than the IPv4 space")
+	}
+
+	tests6 := map[uint64]int{
+		1:         128,
+		2:         127,
+		3:         126,
+		4:         125,
+		255:       120,
+		256:       119,
+		1<<64 - 1: 64,
+		1<<63 - 1: 65,
+		1 << 63:   64,
+	}
+	for n, want := range tests6 {
+		if l := SmallestPrefixForHostsV6(n); l != want {
+			t.Errorf("invalid IPv6 prefix for %d hosts: actual=%v want=%v", n, l,
+				want)
+		}
+	}
+}
+
+func TestGateway(t *testing.T) {
+	tests := []struct {
+		prefix      string
+		first, last IPv4Addr
+	}{
+		{"10.0.0.0/24", IPv4Addr{10, 0, 0, 1}, IPv4Addr{10, 0, 0, 254}},
+		{"10.0.0.77/24", IPv4Addr{10, 0, 0, 1}, IPv4Addr{10, 0, 0, 254}},
+		{"10.0.0.0/31", IPv4Addr{10, 0, 0, 0}, IPv4Addr{10, 0, 0, 1}},
+	}
+	for _, tc := range tests {
+		p, _ := ParseCIDRv4(tc.prefix)
+		if g := p.GatewayFirst(); g != tc.first {
+			t.Errorf("invalid first gateway of %v: actual=%v want=%v", tc.prefix, g,
+				tc.first)
+		}
+		if g := p.GatewayLast(); g != tc.last {
+			t.Errorf("invalid last gateway of %v: actual=%v want=%v", tc.prefix, g,
+				tc.last)
+		}
+	}
+}
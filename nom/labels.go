@@ -0,0 +1,56 @@
+package nom
+
+import "sync"
+
+// AddrLabels maps addresses of any family to labels that describe their
+// roles, such as "gateway" or "dns". The zero value is not usable; use
+// NewAddrLabels to create one.
+//
+// AddrLabels is safe for concurrent use.
+type AddrLabels struct {
+	mu     sync.RWMutex
+	labels map[string]string
+}
+
+// NewAddrLabels creates an empty label store.
+func NewAddrLabels() *AddrLabels {
+	return &AddrLabels{labels: make(map[string]string)}
+}
+
+// labelKey returns the key of a in the store. The family is prepended to the
+// key of the address, so that addresses of different families never collide.
+func labelKey(a Addr) string {
+	return string(AppendAddr(nil, a))
+}
+
+// Set labels a, replacing its previous label if any.
+func (l *AddrLabels) Set(a Addr, label string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.labels[labelKey(a)] = label
+}
+
+// Get returns the label of a.
+func (l *AddrLabels) Get(a Addr) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	label, ok := l.labels[labelKey(a)]
+	return label, ok
+}
+
+// Delete removes the label of a, and returns whether a was labeled.
+func (l *AddrLabels) Delete(a Addr) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	k := labelKey(a)
+	_, ok := l.labels[k]
+	delete(l.labels, k)
+	return ok
+}
+
+// Len returns the number of labeled addresses.
+func (l *AddrLabels) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.labels)
+}
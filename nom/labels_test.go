@@ -0,0 +1,60 @@
+package nom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAddrLabels(t *testing.T) {
+	l := NewAddrLabels()
+	gw := IPv4Addr{10, 0, 0, 1}
+	dns, _ := ParseIPv6("2001:db8::53")
+	mac := MACAddr{0, 0x11, 0x22, 0x33, 0x44, 0x55}
+
+	l.Set(gw, "gateway")
+	l.Set(dns, "dns")
+	l.Set(mac, "router")
+	tests := map[Addr]string{gw: "gateway", dns: "dns", mac: "router"}
+	for a, want := range tests {
+		if label, ok := l.Get(a); !ok || label != want {
+			t.Errorf("invalid label of %v: actual=%v want=%v", a, label, want)
+		}
+	}
+
+	// An IPv4-mapped IPv6 address is not the same address as its IPv4 one.
+	mapped, _ := ParseIPv6("::ffff:10.0.0.1")
+	if label, ok := l.Get(mapped); ok {
+		t.Errorf("%v should not be labeled: %v", mapped, label)
+	}
+
+	l.Set(gw, "core-gateway")
+	if label, _ := l.Get(gw); label != "core-gateway" || l.Len() != 3 {
+		t.Errorf("invalid label after update: %v", label)
+	}
+	if !l.Delete(dns) || l.Delete(dns) {
+		t.Errorf("invalid deletion of %v", dns)
+	}
+	if _, ok := l.Get(dns); ok || l.Len() != 2 {
+		t.Errorf("%v is still labeled after deletion", dns)
+	}
+}
+
+func TestAddrLabelsConcurrent(t *testing.T) {
+	l := NewAddrLabels()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ip := IPv4Addr{10, 0, byte(i), byte(j)}
+				l.Set(ip, "host")
+				l.Get(ip)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if l.Len() != 1000 {
+		t.Errorf("invalid number of labels: actual=%v want=1000", l.Len())
+	}
+}
@@ -0,0 +1,57 @@
+package nom
+
+import "sort"
+
+// MACMatcher matches MAC addresses against a fixed set, such as the allowlist
+// of a port with port security. The addresses are stored as a sorted slice of
+// integers and looked up by binary search, which is compact and faster than a
+// map for sets of up to a few dozen addresses.
+//
+// MACMatcher is immutable and hence safe for concurrent use.
+type MACMatcher struct {
+	macs []uint64
+}
+
+// NewMACMatcher creates a matcher for macs. Duplicates are ignored.
+func NewMACMatcher(macs []MACAddr) *MACMatcher {
+	m := &MACMatcher{macs: make([]uint64, 0, len(macs))}
+	for _, mac := range macs {
+		m.macs = append(m.macs, mac.Uint64())
+	}
+	sort.Sort(uint64s(m.macs))
+	n := 0
+	for i, v := range m.macs {
+		if i == 0 || v != m.macs[n-1] {
+			m.macs[n] = v
+			n++
+		}
+	}
+	m.macs = m.macs[:n]
+	return m
+}
+
+// Len returns the number of addresses in the set.
+func (m *MACMatcher) Len() int {
+	return len(m.macs)
+}
+
+// Match returns whether mac is in the set.
+func (m *MACMatcher) Match(mac MACAddr) bool {
+	v := mac.Uint64()
+	lo, hi := 0, len(m.macs)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if m.macs[mid] < v {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo < len(m.macs) && m.macs[lo] == v
+}
+
+type uint64s []uint64
+
+func (a uint64s) Len() int           { return len(a) }
+func (a uint64s) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a uint64s) Less(i, j int) bool { return a[i] < a[j] }
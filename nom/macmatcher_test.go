@@ -0,0 +1,66 @@
+package nom
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func randMACs(r *rand.Rand, n int) []MACAddr {
+	macs := make([]MACAddr, n)
+	for i := range macs {
+		r.Read(macs[i][:])
+	}
+	return macs
+}
+
+func TestMACMatcher(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 7, 32, 100} {
+		macs := randMACs(r, n)
+		set := make(map[MACAddr]bool)
+		for _, mac := range macs {
+			set[mac] = true
+		}
+		// Duplicates must not affect the set.
+		m := NewMACMatcher(append(macs, macs...))
+		if m.Len() != len(set) {
+			t.Errorf("invalid matcher size: actual=%v want=%v", m.Len(), len(set))
+		}
+		for _, mac := range macs {
+			if !m.Match(mac) {
+				t.Errorf("%v is not matched in a set of %v", mac, n)
+			}
+		}
+		for _, mac := range randMACs(r, 100) {
+			if m.Match(mac) != set[mac] {
+				t.Errorf("invalid match of %v in a set of %v: actual=%v want=%v", mac,
+					n, m.Match(mac), set[mac])
+			}
+		}
+	}
+}
+
+func BenchmarkMACMatcher(b *testing.B) {
+	for _, n := range []int{8, 32, 128} {
+		r := rand.New(rand.NewSource(1))
+		macs := randMACs(r, n)
+		queries := append(randMACs(r, n), macs...)
+
+		b.Run(fmt.Sprintf("matcher/%d", n), func(b *testing.B) {
+			m := NewMACMatcher(macs)
+			for i := 0; i < b.N; i++ {
+				m.Match(queries[i%len(queries)])
+			}
+		})
+		b.Run(fmt.Sprintf("map/%d", n), func(b *testing.B) {
+			set := make(map[string]struct{}, len(macs))
+			for _, mac := range macs {
+				set[mac.Key()] = struct{}{}
+			}
+			for i := 0; i < b.N; i++ {
+				_, _ = set[queries[i%len(queries)].Key()]
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package nom
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// MACTable maps MAC addresses to arbitrary values, such as the port on which
+// a MAC address is learned. Entries can optionally be scoped to a VLAN with
+// PutScoped, GetScoped, and DeleteScoped, so that the same MAC address has
+// independent entries in different VLANs. Put, Get, and Delete use VLAN 0,
+// which 802.1Q reserves for frames without a VLAN. The zero value is not
+// usable; use NewMACTable to create one.
+//
+// MACTable is safe for concurrent use.
+type MACTable struct {
+	mu      sync.RWMutex
+	entries map[ScopedMAC]interface{}
+
+	// The recency of entries, from the most recently used, when the table is
+	// bounded. See NewMACTableLRU.
+	maxEntries int
+	lru        *list.List
+	elems      map[ScopedMAC]*list.Element
+}
+
+// NewMACTable creates an empty MAC table.
+func NewMACTable() *MACTable {
+	return &MACTable{entries: make(map[ScopedMAC]interface{})}
+}
+
+// NewMACTableLRU creates an empty MAC table that holds at most maxEntries
+// entries, like the size-bounded MAC tables of switches. When a new entry
+// would exceed the capacity, the least recently used entry is evicted. Both
+// Put and Get count as a use. If maxEntries is not positive, the table is
+// unbounded as with NewMACTable.
+func NewMACTableLRU(maxEntries int) *MACTable {
+	if maxEntries <= 0 {
+		return NewMACTable()
+	}
+	return &MACTable{
+		entries:    make(map[ScopedMAC]interface{}),
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		elems:      make(map[ScopedMAC]*list.Element),
+	}
+}
+
+// Len returns the number of entries in the table, in all VLANs.
+func (t *MACTable) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.entries)
+}
+
+// Put stores value for mac, replacing its previous value if any.
+func (t *MACTable) Put(mac MACAddr, value interface{}) {
+	t.PutScoped(ScopedMAC{MAC: mac}, value)
+}
+
+// PutScoped stores value for the MAC address in its VLAN, replacing its
+// previous value in that VLAN if any.
+func (t *MACTable) PutScoped(s ScopedMAC, value interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.put(s, value)
+}
+
+func (t *MACTable) put(s ScopedMAC, value interface{}) {
+	t.entries[s] = value
+	if t.lru == nil {
+		return
+	}
+	if e, ok := t.elems[s]; ok {
+		t.lru.MoveToFront(e)
+		return
+	}
+	t.elems[s] = t.lru.PushFront(s)
+	if t.lru.Len() > t.maxEntries {
+		oldest := t.lru.Remove(t.lru.Back()).(ScopedMAC)
+		delete(t.elems, oldest)
+		delete(t.entries, oldest)
+	}
+}
+
+// Get returns the value stored for mac. In a table created by
+// NewMACTableLRU, it also marks mac as the most recently used entry.
+func (t *MACTable) Get(mac MACAddr) (interface{}, bool) {
+	return t.GetScoped(ScopedMAC{MAC: mac})
+}
+
+// GetScoped returns the value stored for the MAC address in its VLAN. See
+// Get.
+func (t *MACTable) GetScoped(s ScopedMAC) (interface{}, bool) {
+	if t.lru == nil {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		v, ok := t.entries[s]
+		return v, ok
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.entries[s]
+	if ok {
+		t.lru.MoveToFront(t.elems[s])
+	}
+	return v, ok
+}
+
+// Delete removes mac from the table, and returns whether it was present.
+func (t *MACTable) Delete(mac MACAddr) bool {
+	return t.DeleteScoped(ScopedMAC{MAC: mac})
+}
+
+// DeleteScoped removes the MAC address in its VLAN from the table, and returns
+// whether it was present. The entries of the MAC address in other VLANs are
+// kept.
+func (t *MACTable) DeleteScoped(s ScopedMAC) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.entries[s]
+	delete(t.entries, s)
+	if e, ok := t.elems[s]; ok {
+		t.lru.Remove(e)
+		delete(t.elems, s)
+	}
+	return ok
+}
+
+// Merge adds the entries of other to the table, which is used to hand off the
+// learned state of a bee to its backup. For MAC addresses present in both
+// tables in the same VLAN, the value is replaced by conflict(a, b) where a is
+// the value in the table and b is the value in other (eg, to keep the entry
+// learned most recently). If conflict is nil, the values in other win.
+//
+// other is not modified. The table and other are never locked at the same
+// time, so concurrent merges in opposite directions cannot deadlock.
+func (t *MACTable) Merge(other *MACTable,
+	conflict func(a, b interface{}) interface{}) {
+
+	if t == other {
+		return
+	}
+
+	other.mu.RLock()
+	entries := make(map[ScopedMAC]interface{}, len(other.entries))
+	for s, v := range other.entries {
+		entries[s] = v
+	}
+	other.mu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for s, b := range entries {
+		if a, ok := t.entries[s]; ok && conflict != nil {
+			b = conflict(a, b)
+		}
+		t.put(s, b)
+	}
+}
+
+// Flow is a forwarding rule derived from learned L2 state: packets destined to
+// DstMAC are sent out of OutPort.
+type Flow struct {
+	DstMAC  MaskedMACAddr
+	OutPort uint32
+}
+
+// ToFlows returns one exact-match flow per MAC address in the table, ordered
+// by MAC address, to program the learned state in the data plane. outPortFor
+// returns the output port of an entry's value. Since Flow does not match on
+// VLANs, only the entries stored without a VLAN (ie, with Put) are included.
+func (t *MACTable) ToFlows(outPortFor func(value interface{}) uint32) []Flow {
+	t.mu.RLock()
+	var macs []MACAddr
+	for s := range t.entries {
+		if s.VLAN == 0 {
+			macs = append(macs, s.MAC)
+		}
+	}
+	values := make([]interface{}, len(macs))
+	sort.Sort(macAddrs(macs))
+	for i, mac := range macs {
+		values[i] = t.entries[ScopedMAC{MAC: mac}]
+	}
+	t.mu.RUnlock()
+
+	flows := make([]Flow, len(macs))
+	for i, mac := range macs {
+		flows[i] = Flow{
+			DstMAC:  MaskedMACAddr{Addr: mac, Mask: BroadcastMAC},
+			OutPort: outPortFor(values[i]),
+		}
+	}
+	return flows
+}
+
+// CleanMACs returns the MAC addresses in in that can identify a host, without
+// duplicates and in the order of their first occurrence. It drops the zero
+// address 00:00:00:00:00:00 and every group address (see MACAddr.IsGroup),
+// which includes the broadcast address and all multicast addresses. This is
+// useful to sanitize learned addresses before they are stored in a table.
+func CleanMACs(in []MACAddr) []MACAddr {
+	out := make([]MACAddr, 0, len(in))
+	seen := make(map[MACAddr]struct{}, len(in))
+	for _, mac := range in {
+		if mac == (MACAddr{}) || mac.IsGroup() {
+			continue
+		}
+		if _, ok := seen[mac]; ok {
+			continue
+		}
+		seen[mac] = struct{}{}
+		out = append(out, mac)
+	}
+	return out
+}
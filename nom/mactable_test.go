@@ -0,0 +1,187 @@
+package nom
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMACTableMergeDisjoint(t *testing.T) {
+	a := NewMACTable()
+	a.Put(MACAddr{0, 0, 0, 0, 0, 1}, 1)
+	b := NewMACTable()
+	b.Put(MACAddr{0, 0, 0, 0, 0, 2}, 2)
+
+	a.Merge(b, nil)
+	if a.Len() != 2 {
+		t.Errorf("invalid table size: actual=%v want=2", a.Len())
+	}
+	for i := 1; i <= 2; i++ {
+		mac := MACAddr{0, 0, 0, 0, 0, byte(i)}
+		if v, ok := a.Get(mac); !ok || v != i {
+			t.Errorf("invalid value for %v: actual=%v want=%v", mac, v, i)
+		}
+	}
+	if b.Len() != 1 {
+		t.Errorf("merge modified the other table: %v", b.Len())
+	}
+}
+
+func TestMACTableMergeOverlapping(t *testing.T) {
+	mac := MACAddr{0, 0, 0, 0, 0, 1}
+	a := NewMACTable()
+	a.Put(mac, 10)
+	b := NewMACTable()
+	b.Put(mac, 20)
+
+	a.Merge(b, func(x, y interface{}) interface{} {
+		if x.(int) > y.(int) {
+			return x
+		}
+		return y
+	})
+	if v, _ := a.Get(mac); v != 20 {
+		t.Errorf("invalid merged value: actual=%v want=20", v)
+	}
+
+	b.Put(mac, 5)
+	a.Merge(b, nil)
+	if v, _ := a.Get(mac); v != 5 {
+		t.Errorf("other table should win without conflict: actual=%v want=5", v)
+	}
+}
+
+func TestMACTableMergeConcurrent(t *testing.T) {
+	a := NewMACTable()
+	b := NewMACTable()
+	for i := 0; i < 100; i++ {
+		a.Put(MACAddr{0, 0, 0, 0, 0, byte(i)}, i)
+		b.Put(MACAddr{0, 0, 0, 0, 1, byte(i)}, i)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.Merge(b, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			b.Merge(a, nil)
+		}()
+	}
+	wg.Wait()
+	if a.Len() != 200 || b.Len() != 200 {
+		t.Errorf("invalid table sizes after merge: %v %v", a.Len(), b.Len())
+	}
+}
+
+func TestMACTableLRUEviction(t *testing.T) {
+	tbl := NewMACTableLRU(2)
+	m1 := MACAddr{0, 0, 0, 0, 0, 1}
+	m2 := MACAddr{0, 0, 0, 0, 0, 2}
+	m3 := MACAddr{0, 0, 0, 0, 0, 3}
+
+	tbl.Put(m1, 1)
+	tbl.Put(m2, 2)
+	tbl.Put(m3, 3)
+	if tbl.Len() != 2 {
+		t.Errorf("invalid table size: actual=%v want=2", tbl.Len())
+	}
+	if _, ok := tbl.Get(m1); ok {
+		t.Errorf("the oldest entry %v is not evicted", m1)
+	}
+	for _, mac := range []MACAddr{m2, m3} {
+		if _, ok := tbl.Get(mac); !ok {
+			t.Errorf("%v is evicted", mac)
+		}
+	}
+}
+
+func TestMACTableLRUGetRefreshes(t *testing.T) {
+	tbl := NewMACTableLRU(2)
+	m1 := MACAddr{0, 0, 0, 0, 0, 1}
+	m2 := MACAddr{0, 0, 0, 0, 0, 2}
+	m3 := MACAddr{0, 0, 0, 0, 0, 3}
+
+	tbl.Put(m1, 1)
+	tbl.Put(m2, 2)
+	tbl.Get(m1)
+	tbl.Put(m3, 3)
+	if _, ok := tbl.Get(m2); ok {
+		t.Errorf("the least recently used entry %v is not evicted", m2)
+	}
+	if v, ok := tbl.Get(m1); !ok || v != 1 {
+		t.Errorf("the recently used entry %v is evicted", m1)
+	}
+
+	tbl.Delete(m1)
+	tbl.Put(m2, 2)
+	if tbl.Len() != 2 {
+		t.Errorf("invalid table size after delete: actual=%v want=2", tbl.Len())
+	}
+}
+
+func TestMACTableLRUUnbounded(t *testing.T) {
+	for _, max := range []int{0, -1} {
+		tbl := NewMACTableLRU(max)
+		for i := 0; i < 3; i++ {
+			tbl.Put(MACAddr{0, 0, 0, 0, 0, byte(i)}, i)
+		}
+		if tbl.Len() != 3 {
+			t.Errorf("invalid size of a table with max %d: actual=%v want=3", max,
+				tbl.Len())
+		}
+	}
+}
+
+func TestCleanMACs(t *testing.T) {
+	h1 := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	h2 := MACAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	in := []MACAddr{
+		h2,
+		{}, // Zero.
+		h1,
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, // Broadcast.
+		{0x01, 0x00, 0x5E, 0x00, 0x00, 0x01}, // IPv4 multicast.
+		{0x33, 0x33, 0x00, 0x00, 0x00, 0x01}, // IPv6 multicast.
+		{0x01, 0x80, 0xC2, 0x00, 0x00, 0x0E}, // LLDP.
+		h2,
+	}
+	out := CleanMACs(in)
+	want := []MACAddr{h2, h1}
+	if len(out) != len(want) {
+		t.Fatalf("invalid cleaned MACs: actual=%v want=%v", out, want)
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Errorf("invalid cleaned MAC: actual=%v want=%v", out[i], want[i])
+		}
+	}
+}
+
+func TestMACTableToFlows(t *testing.T) {
+	tbl := NewMACTable()
+	tbl.Put(MACAddr{0, 0, 0, 0, 0, 2}, 20)
+	tbl.Put(MACAddr{0, 0, 0, 0, 0, 1}, 10)
+	tbl.Put(MACAddr{0, 0, 0, 0, 0, 3}, 10)
+
+	flows := tbl.ToFlows(func(v interface{}) uint32 {
+		return uint32(v.(int))
+	})
+	if len(flows) != tbl.Len() {
+		t.Fatalf("invalid number of flows: actual=%v want=%v", len(flows),
+			tbl.Len())
+	}
+	for i, f := range flows {
+		mac := MACAddr{0, 0, 0, 0, 0, byte(i + 1)}
+		if !f.DstMAC.IsExact() || f.DstMAC.Addr != mac {
+			t.Errorf("invalid match of flow %v: actual=%v want=%v", i, f.DstMAC, mac)
+		}
+		v, _ := tbl.Get(mac)
+		if f.OutPort != uint32(v.(int)) {
+			t.Errorf("invalid output port for %v: actual=%v want=%v", mac,
+				f.OutPort, v)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package nom
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// macTableVersion is the version of the format written by MACTable.Save. It
+// must be incremented whenever the format changes. Version 1 only has the
+// entries, and version 2 adds the capacity and the recency of bounded tables.
+const macTableVersion = 2
+
+// macTableRecord is a MAC address, its VLAN, and its value as persisted by
+// MACTable.Save. Records written before VLANs were persisted decode with VLAN
+// 0.
+type macTableRecord struct {
+	MAC   MACAddr
+	VLAN  uint16
+	Value interface{}
+}
+
+// macTableState is a table as persisted by MACTable.Save. For tables created
+// by NewMACTableLRU, MaxEntries is their capacity and Records are ordered
+// from the least to the most recently used, so that putting them in order
+// restores their recency. Otherwise, MaxEntries is 0.
+type macTableState struct {
+	MaxEntries int
+	Records    []macTableRecord
+}
+
+type macTableRecords []macTableRecord
+
+func (s macTableRecords) Len() int      { return len(s) }
+func (s macTableRecords) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s macTableRecords) Less(i, j int) bool {
+	if s[i].MAC != s[j].MAC {
+		return s[i].MAC.Less(s[j].MAC)
+	}
+	return s[i].VLAN < s[j].VLAN
+}
+
+// Save writes the entries of the table to w, so that a controller can
+// checkpoint the learned L2 state and restore it with LoadMACTable after a
+// restart. The format is a version byte followed by the capacity of the table
+// and its entries encoded with gob. Entries are sorted by MAC address and
+// VLAN, or by recency for tables created by NewMACTableLRU. Since values are
+// stored as interface{}, their concrete types must be registered with
+// gob.Register in both the saving and the loading processes.
+func (t *MACTable) Save(w io.Writer) error {
+	t.mu.RLock()
+	state := macTableState{
+		MaxEntries: t.maxEntries,
+		Records:    make([]macTableRecord, 0, len(t.entries)),
+	}
+	if t.lru != nil {
+		for e := t.lru.Back(); e != nil; e = e.Prev() {
+			s := e.Value.(ScopedMAC)
+			state.Records = append(state.Records, macTableRecord{MAC: s.MAC,
+				VLAN: s.VLAN, Value: t.entries[s]})
+		}
+	} else {
+		for s, v := range t.entries {
+			state.Records = append(state.Records, macTableRecord{MAC: s.MAC,
+				VLAN: s.VLAN, Value: v})
+		}
+		sort.Sort(macTableRecords(state.Records))
+	}
+	t.mu.RUnlock()
+
+	if _, err := w.Write([]byte{macTableVersion}); err != nil {
+		return fmt.Errorf("cannot write the MAC table: %v", err)
+	}
+	if err := gob.NewEncoder(w).Encode(state); err != nil {
+		return fmt.Errorf("cannot encode the MAC table: %v", err)
+	}
+	return nil
+}
+
+// LoadMACTable reads a table written by MACTable.Save. Bounded tables are
+// restored with their capacity and recency. Tables saved in version 1 of the
+// format are loaded as unbounded tables. It returns an error if the table was
+// saved in an unsupported version of the format.
+func LoadMACTable(r io.Reader) (*MACTable, error) {
+	br := bufio.NewReader(r)
+	v, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the MAC table: %v", err)
+	}
+
+	var state macTableState
+	dec := gob.NewDecoder(br)
+	switch v {
+	case 1:
+		err = dec.Decode(&state.Records)
+	case macTableVersion:
+		err = dec.Decode(&state)
+	default:
+		return nil, fmt.Errorf("unsupported MAC table version %d", v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode the MAC table: %v", err)
+	}
+
+	t := NewMACTableLRU(state.MaxEntries)
+	for _, rec := range state.Records {
+		t.put(ScopedMAC{MAC: rec.MAC, VLAN: rec.VLAN}, rec.Value)
+	}
+	return t, nil
+}
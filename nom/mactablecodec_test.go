@@ -0,0 +1,99 @@
+package nom
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func TestMACTableSaveLoad(t *testing.T) {
+	tbl := NewMACTable()
+	for i := 1; i <= 10; i++ {
+		tbl.Put(MACAddr{0x02, 0, 0, 0, 0, byte(i)}, uint32(i))
+	}
+	scoped := ScopedMAC{MAC: MACAddr{0x02, 0, 0, 0, 0, 1}, VLAN: 10}
+	tbl.PutScoped(scoped, uint32(100))
+
+	var buf bytes.Buffer
+	if err := tbl.Save(&buf); err != nil {
+		t.Fatalf("cannot save the table: %v", err)
+	}
+	loaded, err := LoadMACTable(&buf)
+	if err != nil {
+		t.Fatalf("cannot load the table: %v", err)
+	}
+	if loaded.Len() != tbl.Len() {
+		t.Errorf("invalid table size: actual=%v want=%v", loaded.Len(), tbl.Len())
+	}
+	for i := 1; i <= 10; i++ {
+		mac := MACAddr{0x02, 0, 0, 0, 0, byte(i)}
+		if v, ok := loaded.Get(mac); !ok || v != uint32(i) {
+			t.Errorf("invalid value for %v: actual=%v want=%v", mac, v, i)
+		}
+	}
+	if v, ok := loaded.GetScoped(scoped); !ok || v != uint32(100) {
+		t.Errorf("invalid value for %v: actual=%v want=100", scoped, v)
+	}
+}
+
+func TestMACTableSaveLoadLRU(t *testing.T) {
+	tbl := NewMACTableLRU(2)
+	m1 := MACAddr{0, 0, 0, 0, 0, 1}
+	m2 := MACAddr{0, 0, 0, 0, 0, 2}
+	m3 := MACAddr{0, 0, 0, 0, 0, 3}
+	tbl.Put(m2, 2)
+	tbl.Put(m1, 1)
+	// m2 is the most recently used entry, although m1 was put last.
+	tbl.Get(m2)
+
+	var buf bytes.Buffer
+	if err := tbl.Save(&buf); err != nil {
+		t.Fatalf("cannot save the table: %v", err)
+	}
+	loaded, err := LoadMACTable(&buf)
+	if err != nil {
+		t.Fatalf("cannot load the table: %v", err)
+	}
+	loaded.Put(m3, 3)
+	if loaded.Len() != 2 {
+		t.Errorf("invalid table size: actual=%v want=2", loaded.Len())
+	}
+	if _, ok := loaded.Get(m1); ok {
+		t.Errorf("the least recently used entry %v is not evicted", m1)
+	}
+	if v, ok := loaded.Get(m2); !ok || v != 2 {
+		t.Errorf("the recently used entry %v is evicted", m2)
+	}
+}
+
+func TestMACTableLoadVersion1(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteByte(1)
+	mac := MACAddr{0x02, 0, 0, 0, 0, 1}
+	records := []macTableRecord{{MAC: mac, Value: uint32(1)}}
+	if err := gob.NewEncoder(&buf).Encode(records); err != nil {
+		t.Fatalf("cannot encode the table: %v", err)
+	}
+	loaded, err := LoadMACTable(&buf)
+	if err != nil {
+		t.Fatalf("cannot load the table: %v", err)
+	}
+	if v, ok := loaded.Get(mac); !ok || v != uint32(1) || loaded.Len() != 1 {
+		t.Errorf("invalid value for %v: actual=%v want=1", mac, v)
+	}
+}
+
+func TestMACTableLoadFutureVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewMACTable().Save(&buf); err != nil {
+		t.Fatalf("cannot save the table: %v", err)
+	}
+	b := buf.Bytes()
+	b[0] = macTableVersion + 1
+	if _, err := LoadMACTable(bytes.NewReader(b)); err == nil {
+		t.Errorf("loaded a table with a future version")
+	}
+	if _, err := LoadMACTable(bytes.NewReader(nil)); err == nil {
+		t.Errorf("loaded an empty table file")
+	}
+}
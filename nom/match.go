@@ -0,0 +1,47 @@
+package nom
+
+// PreparedIPv4Match is a MaskedIPv4Addr prepared for high-rate matching. Use
+// PrepareIPv4Match to create one.
+type PreparedIPv4Match struct {
+	prefix     MaskedIPv4Addr
+	addr       uint32
+	mask       uint32
+	contiguous bool
+}
+
+// PrepareIPv4Match prepares mi for high-rate matching. For contiguous masks
+// (ie, prefixes), the masked address and the mask are precomputed as
+// integers so that each match is a single AND and a comparison. ACL rules
+// with non-contiguous masks fall back to MaskedIPv4Addr.Match, so the
+// semantics of matching are the same for all masks.
+func PrepareIPv4Match(mi MaskedIPv4Addr) PreparedIPv4Match {
+	p := PreparedIPv4Match{prefix: mi}
+	if IsValidNetmask4(mi.Mask) {
+		p.contiguous = true
+		p.mask = mi.Mask.Uint32()
+		p.addr = mi.Addr.Uint32() & p.mask
+	}
+	return p
+}
+
+// Prefix returns the masked address that p is prepared for.
+func (p PreparedIPv4Match) Prefix() MaskedIPv4Addr {
+	return p.prefix
+}
+
+// Match returns whether ip matches the prepared masked address.
+func (p PreparedIPv4Match) Match(ip IPv4Addr) bool {
+	if p.contiguous {
+		return ip.Uint32()&p.mask == p.addr
+	}
+	return p.prefix.Match(ip)
+}
+
+// MatchUint32 is like Match but accepts the IP address in the integer form
+// returned by IPv4Addr.Uint32, which is the fastest path for prefixes.
+func (p PreparedIPv4Match) MatchUint32(ip uint32) bool {
+	if p.contiguous {
+		return ip&p.mask == p.addr
+	}
+	return p.prefix.MatchUint32(ip)
+}
@@ -0,0 +1,56 @@
+package nom
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPreparedIPv4Match(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var mi MaskedIPv4Addr
+		mi.Addr.FromUint(r.Uint32())
+		if i%2 == 0 {
+			mi.Mask = MaskFromPrefixLen4(r.Intn(33))
+		} else {
+			mi.Mask.FromUint(r.Uint32())
+		}
+
+		p := PrepareIPv4Match(mi)
+		if p.contiguous != (i%2 == 0 || IsValidNetmask4(mi.Mask)) {
+			t.Errorf("invalid fast path for %v", mi.Mask)
+		}
+		for j := 0; j < 10; j++ {
+			var ip IPv4Addr
+			ip.FromUint(r.Uint32())
+			if j%2 == 0 {
+				ip = mi.Addr
+				ip[3] = byte(r.Intn(256))
+			}
+			if p.Match(ip) != mi.Match(ip) {
+				t.Errorf("invalid match of %v against %v: actual=%v want=%v", ip, mi,
+					p.Match(ip), mi.Match(ip))
+			}
+			if p.MatchUint32(ip.Uint32()) != mi.Match(ip) {
+				t.Errorf("invalid uint32 match of %v against %v: actual=%v want=%v",
+					ip, mi, p.MatchUint32(ip.Uint32()), mi.Match(ip))
+			}
+		}
+	}
+}
+
+func BenchmarkPreparedIPv4Match(b *testing.B) {
+	p := PrepareIPv4Match(CIDRToMaskedIPv4(0xC0A80100, 24))
+	ip := IPv4Addr{192, 168, 1, 7}
+	for i := 0; i < b.N; i++ {
+		p.Match(ip)
+	}
+}
+
+func BenchmarkPreparedIPv4MatchUint32(b *testing.B) {
+	p := PrepareIPv4Match(CIDRToMaskedIPv4(0xC0A80100, 24))
+	ip := IPv4Addr{192, 168, 1, 7}.Uint32()
+	for i := 0; i < b.N; i++ {
+		p.MatchUint32(ip)
+	}
+}
@@ -0,0 +1,30 @@
+package nom
+
+// MulticastMACForIPv4 returns the Ethernet multicast address of the IPv4
+// multicast group ip as defined in RFC 1112: 01:00:5e followed by the lower
+// 23 bits of ip.
+func MulticastMACForIPv4(ip IPv4Addr) MACAddr {
+	return MACAddr{0x01, 0x00, 0x5E, ip[1] & 0x7F, ip[2], ip[3]}
+}
+
+// MulticastMACIsForIPv4 returns whether mac can be the Ethernet multicast
+// address of the IPv4 multicast group ip. Since only the lower 23 of the 28
+// group bits are mapped, 32 IPv4 groups share each multicast MAC address;
+// ie, this checks consistency but cannot identify the group.
+func MulticastMACIsForIPv4(mac MACAddr, ip IPv4Addr) bool {
+	return ip.IsMulticast() && mac == MulticastMACForIPv4(ip)
+}
+
+// MulticastMACForIPv6 returns the Ethernet multicast address of the IPv6
+// multicast group ip as defined in RFC 2464: 33:33 followed by the lower 32
+// bits of ip.
+func MulticastMACForIPv6(ip IPv6Addr) MACAddr {
+	return MACAddr{0x33, 0x33, ip[12], ip[13], ip[14], ip[15]}
+}
+
+// MulticastMACIsForIPv6 returns whether mac is the Ethernet multicast address
+// of the IPv6 multicast group ip. Unlike IPv4, the mapping is exact over the
+// lower 32 bits of the group address.
+func MulticastMACIsForIPv6(mac MACAddr, ip IPv6Addr) bool {
+	return ip[0] == 0xFF && mac == MulticastMACForIPv6(ip)
+}
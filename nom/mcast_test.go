@@ -0,0 +1,38 @@
+package nom
+
+import "testing"
+
+func TestMulticastMACIsForIPv4(t *testing.T) {
+	mac := MACAddr{0x01, 0x00, 0x5E, 0x00, 0x00, 0x05}
+	ips := map[IPv4Addr]bool{
+		IPv4Addr{224, 0, 0, 5}:   true,
+		IPv4Addr{239, 128, 0, 5}: true, // Shares the MAC due to the 32:1 mapping.
+		IPv4Addr{224, 0, 0, 6}:   false,
+		IPv4Addr{10, 0, 0, 5}:    false,
+	}
+	for ip, want := range ips {
+		if MulticastMACIsForIPv4(mac, ip) != want {
+			t.Errorf("invalid multicast mapping of %v to %v: actual=%v want=%v", ip,
+				mac, !want, want)
+		}
+	}
+	if m := MulticastMACForIPv4(IPv4Addr{239, 255, 255, 250}); m !=
+		(MACAddr{0x01, 0x00, 0x5E, 0x7F, 0xFF, 0xFA}) {
+
+		t.Errorf("invalid multicast mac: actual=%v want=01:00:5e:7f:ff:fa", m)
+	}
+}
+
+func TestMulticastMACIsForIPv6(t *testing.T) {
+	ip := IPv6Addr{0xFF, 0x02, 15: 0x01}
+	mac := MACAddr{0x33, 0x33, 0x00, 0x00, 0x00, 0x01}
+	if !MulticastMACIsForIPv6(mac, ip) {
+		t.Errorf("%v should be the multicast mac of %v", mac, ip)
+	}
+	if MulticastMACIsForIPv6(mac, IPv6Addr{0x20, 0x01, 15: 0x01}) {
+		t.Errorf("%v should not map to a unicast address", mac)
+	}
+	if MulticastMACIsForIPv6(MACAddr{0x33, 0x33, 0, 0, 0, 2}, ip) {
+		t.Errorf("unexpected mapping to %v", ip)
+	}
+}
@@ -0,0 +1,55 @@
+package nom
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NormalizeIPv4Prefixes cleans up a set of prefixes before they are loaded
+// into a trie or a rule table. It clears the host bits of each prefix,
+// removes duplicates, and sorts the prefixes by specificity: longer prefixes
+// come first, and prefixes of the same length are sorted by address.
+//
+// Prefixes with non-contiguous masks are left out of the result, and are all
+// reported in the returned error. The valid prefixes are returned even when
+// there is an error, so that callers can choose to proceed with them.
+func NormalizeIPv4Prefixes(in []MaskedIPv4Addr) ([]MaskedIPv4Addr, error) {
+	var invalid []string
+	seen := make(map[MaskedIPv4Addr]struct{}, len(in))
+	out := make([]MaskedIPv4Addr, 0, len(in))
+	for i, mi := range in {
+		if !IsValidNetmask4(mi.Mask) {
+			invalid = append(invalid, fmt.Sprintf("#%d (%v/%v)", i, mi.Addr,
+				mi.Mask))
+			continue
+		}
+		mi = mi.Canonicalize()
+		if _, ok := seen[mi]; ok {
+			continue
+		}
+		seen[mi] = struct{}{}
+		out = append(out, mi)
+	}
+	sort.Sort(bySpecificityIPv4(out))
+
+	if len(invalid) != 0 {
+		return out, fmt.Errorf("non-contiguous masks in prefixes %s",
+			strings.Join(invalid, ", "))
+	}
+	return out, nil
+}
+
+// bySpecificityIPv4 sorts canonical prefixes from the longest to the
+// shortest, and then by address.
+type bySpecificityIPv4 []MaskedIPv4Addr
+
+func (s bySpecificityIPv4) Len() int      { return len(s) }
+func (s bySpecificityIPv4) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s bySpecificityIPv4) Less(i, j int) bool {
+	li, lj := s[i].PrefixLen(), s[j].PrefixLen()
+	if li != lj {
+		return li > lj
+	}
+	return s[i].Addr.Less(s[j].Addr)
+}
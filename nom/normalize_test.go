@@ -0,0 +1,54 @@
+package nom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeIPv4Prefixes(t *testing.T) {
+	var in []MaskedIPv4Addr
+	for _, s := range []string{
+		"10.0.0.0/8",
+		"192.168.1.7/24",
+		"10.1.2.3/8",
+		"0.0.0.0/0",
+		"192.168.1.0 255.255.255.0",
+		"172.16.0.0/12",
+		"10.0.0.1/32",
+		"10.0.0.0 255.0.255.0",
+	} {
+		mi, err := ParseCIDRv4(s, AllowNonContiguous())
+		if err != nil {
+			t.Fatalf("cannot parse %q: %v", s, err)
+		}
+		in = append(in, mi)
+	}
+
+	out, err := NormalizeIPv4Prefixes(in)
+	want := []string{
+		"10.0.0.1/32",
+		"192.168.1.0/24",
+		"172.16.0.0/12",
+		"10.0.0.0/8",
+		"0.0.0.0/0",
+	}
+	if len(out) != len(want) {
+		t.Fatalf("invalid normalized prefixes: actual=%v want=%v", out, want)
+	}
+	for i := range want {
+		if out[i].String() != want[i] {
+			t.Errorf("invalid prefix at %d: actual=%v want=%v", i, out[i], want[i])
+		}
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "#7 (10.0.0.0/255.0.255.0)") {
+		t.Errorf("invalid error for a non-contiguous mask: %v", err)
+	}
+
+	if _, err := NormalizeIPv4Prefixes(in[:7]); err != nil {
+		t.Errorf("unexpected error for valid prefixes: %v", err)
+	}
+	if out, err := NormalizeIPv4Prefixes(nil); err != nil || len(out) != 0 {
+		t.Errorf("invalid result for no prefixes: %v %v", out, err)
+	}
+}
@@ -0,0 +1,23 @@
+package nom
+
+// OXM returns the value and the mask of the prefix as placed in the
+// OXM_OF_IPV4_SRC and OXM_OF_IPV4_DST fields of OpenFlow matches. The host
+// bits of the value are cleared, since OpenFlow requires the bits of the
+// value that are not in the mask to be zero.
+func (mi MaskedIPv4Addr) OXM() (value [4]byte, mask [4]byte) {
+	return mi.Addr.Mask(mi.Mask), mi.Mask
+}
+
+// OXM returns the value and the mask of the prefix as placed in the
+// OXM_OF_IPV6_SRC and OXM_OF_IPV6_DST fields of OpenFlow matches. See
+// MaskedIPv4Addr.OXM.
+func (mi MaskedIPv6Addr) OXM() (value [16]byte, mask [16]byte) {
+	return mi.Addr.Mask(mi.Mask), mi.Mask
+}
+
+// OXM returns the value and the mask of the masked address as placed in the
+// OXM_OF_ETH_SRC and OXM_OF_ETH_DST fields of OpenFlow matches. See
+// MaskedIPv4Addr.OXM.
+func (mm MaskedMACAddr) OXM() (value [6]byte, mask [6]byte) {
+	return mm.Addr.Mask(mm.Mask), mm.Mask
+}
@@ -0,0 +1,31 @@
+package nom
+
+import "testing"
+
+func TestOXM(t *testing.T) {
+	mi := MaskedIPv4Addr{
+		Addr: IPv4Addr{10, 1, 2, 3},
+		Mask: IPv4Addr{255, 255, 0, 0},
+	}
+	if v, m := mi.OXM(); v != [4]byte{10, 1, 0, 0} || m != [4]byte(mi.Mask) {
+		t.Errorf("invalid OXM of %v: value=%v mask=%v", mi, v, m)
+	}
+
+	mi6, _ := ParseCIDRv6("2001:db8::1/32")
+	v6, m6 := mi6.OXM()
+	if want, _ := ParseIPv6("2001:db8::"); v6 != [16]byte(want) {
+		t.Errorf("invalid OXM value of %v: actual=%v want=%v", mi6, v6, want)
+	}
+	if m6 != [16]byte(mi6.Mask) {
+		t.Errorf("invalid OXM mask of %v: %v", mi6, m6)
+	}
+
+	mm := MaskedMACAddr{
+		Addr: MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+		Mask: MACAddr{0xFF, 0xFF, 0xFF},
+	}
+	v, m := mm.OXM()
+	if v != [6]byte{0x00, 0x11, 0x22} || m != [6]byte(mm.Mask) {
+		t.Errorf("invalid OXM of %v: value=%v mask=%v", mm, v, m)
+	}
+}
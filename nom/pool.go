@@ -0,0 +1,242 @@
+package nom
+
+import (
+	"fmt"
+	"sync"
+)
+
+// IPv4Pool allocates addresses and aligned sub-prefixes out of an IPv4
+// prefix using a buddy allocator: free space is kept as aligned blocks, an
+// allocation splits the smallest free block that fits into halves (buddies)
+// until it reaches the requested size, and a release merges a block with its
+// buddy whenever both are free.
+//
+// The pool hands out every address in its space, including the network and
+// the broadcast addresses of the space.
+//
+// IPv4Pool is safe for concurrent use.
+type IPv4Pool struct {
+	mu    sync.Mutex
+	space MaskedIPv4Addr
+	// free[l] is the set of the network addresses of the free /l blocks.
+	free      [33]map[uint32]struct{}
+	allocated map[uint32]int
+	reserved  map[uint32]int
+}
+
+// NewIPv4Pool creates a pool that allocates from space. The mask of space
+// must be contiguous.
+func NewIPv4Pool(space MaskedIPv4Addr) (*IPv4Pool, error) {
+	if !IsValidNetmask4(space.Mask) {
+		return nil, fmt.Errorf("%v has a non-contiguous mask %v", space.Addr,
+			space.Mask)
+	}
+	p := &IPv4Pool{
+		space:     space.Canonicalize(),
+		allocated: make(map[uint32]int),
+		reserved:  make(map[uint32]int),
+	}
+	for l := range p.free {
+		p.free[l] = make(map[uint32]struct{})
+	}
+	p.free[p.space.PrefixLen()][p.space.Addr.Uint32()] = struct{}{}
+	return p, nil
+}
+
+// Space returns the prefix that the pool allocates from.
+func (p *IPv4Pool) Space() MaskedIPv4Addr {
+	return p.space
+}
+
+// Allocate allocates the lowest free address of the pool.
+func (p *IPv4Pool) Allocate() (IPv4Addr, error) {
+	mi, err := p.AllocatePrefix(32)
+	return mi.Addr, err
+}
+
+// Release releases an address allocated by Allocate.
+func (p *IPv4Pool) Release(ip IPv4Addr) error {
+	return p.ReleasePrefix(MaskedIPv4Addr{Addr: ip, Mask: MaskNoneIPV4})
+}
+
+// AllocatePrefix allocates a free prefix of length prefixLen, aligned on its
+// size. It carves the prefix out of the smallest free block that can hold
+// it, and prefers lower addresses among blocks of the same size, which keeps
+// large blocks available for as long as possible.
+func (p *IPv4Pool) AllocatePrefix(prefixLen int) (MaskedIPv4Addr, error) {
+	if prefixLen < p.space.PrefixLen() || prefixLen > 32 {
+		return MaskedIPv4Addr{}, fmt.Errorf("cannot allocate a /%d from %v",
+			prefixLen, p.space)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l := prefixLen
+	for ; l >= p.space.PrefixLen() && len(p.free[l]) == 0; l-- {
+	}
+	if l < p.space.PrefixLen() {
+		return MaskedIPv4Addr{}, fmt.Errorf("no free /%d in %v", prefixLen,
+			p.space)
+	}
+
+	n := lowestBlock(p.free[l])
+	delete(p.free[l], n)
+	for ; l < prefixLen; l++ {
+		// Keep the upper half free and continue splitting the lower half.
+		p.free[l+1][n|1<<uint(32-l-1)] = struct{}{}
+	}
+	p.allocated[n] = prefixLen
+	return CIDRToMaskedIPv4(n, uint(prefixLen)), nil
+}
+
+// ReleasePrefix releases a prefix allocated by AllocatePrefix, and merges it
+// with its free buddies.
+func (p *IPv4Pool) ReleasePrefix(prefix MaskedIPv4Addr) error {
+	n, l := prefix.Network().Uint32(), prefix.PrefixLen()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	al, ok := p.allocated[n]
+	if !ok || al != l || !IsValidNetmask4(prefix.Mask) {
+		return fmt.Errorf("%v is not allocated from the pool", prefix)
+	}
+	delete(p.allocated, n)
+	p.freeBlock(n, l)
+	return nil
+}
+
+// freeBlock adds the block n/l to the free lists, merging it with its buddy
+// as long as the buddy is free.
+func (p *IPv4Pool) freeBlock(n uint32, l int) {
+	for ; l > p.space.PrefixLen(); l-- {
+		buddy := n ^ 1<<uint(32-l)
+		if _, ok := p.free[l][buddy]; !ok {
+			break
+		}
+		delete(p.free[l], buddy)
+		n &^= 1 << uint(32-l)
+	}
+	p.free[l][n] = struct{}{}
+}
+
+// Reserve excludes ip from allocation, eg, because it is the address of a
+// gateway or is statically assigned. It fails if ip is outside the pool or is
+// already allocated or reserved.
+func (p *IPv4Pool) Reserve(ip IPv4Addr) error {
+	return p.ReservePrefix(MaskedIPv4Addr{Addr: ip, Mask: MaskNoneIPV4})
+}
+
+// ReservePrefix excludes all addresses in prefix from allocation. It fails if
+// prefix is outside the pool or overlaps an allocated or reserved prefix.
+func (p *IPv4Pool) ReservePrefix(prefix MaskedIPv4Addr) error {
+	if !IsValidNetmask4(prefix.Mask) || !p.space.Subsumes(prefix) {
+		return fmt.Errorf("%v is not a prefix within %v", prefix, p.space)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n, l := prefix.Network().Uint32(), prefix.PrefixLen()
+	if !p.take(n, l) {
+		return fmt.Errorf("%v is already allocated or reserved", prefix)
+	}
+	p.reserved[n] = l
+	return nil
+}
+
+// Unreserve makes a reserved address available for allocation again.
+func (p *IPv4Pool) Unreserve(ip IPv4Addr) error {
+	return p.UnreservePrefix(MaskedIPv4Addr{Addr: ip, Mask: MaskNoneIPV4})
+}
+
+// UnreservePrefix makes a reserved prefix available for allocation again.
+func (p *IPv4Pool) UnreservePrefix(prefix MaskedIPv4Addr) error {
+	n, l := prefix.Network().Uint32(), prefix.PrefixLen()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rl, ok := p.reserved[n]
+	if !ok || rl != l || !IsValidNetmask4(prefix.Mask) {
+		return fmt.Errorf("%v is not reserved in the pool", prefix)
+	}
+	delete(p.reserved, n)
+	p.freeBlock(n, l)
+	return nil
+}
+
+// take removes the block n/l from the free lists, by splitting the free block
+// that contains it. It returns false if n/l is not entirely free.
+func (p *IPv4Pool) take(n uint32, l int) bool {
+	k := l
+	for ; k >= p.space.PrefixLen(); k-- {
+		if _, ok := p.free[k][n&^(1<<uint(32-k)-1)]; ok {
+			break
+		}
+	}
+	if k < p.space.PrefixLen() {
+		return false
+	}
+
+	delete(p.free[k], n&^(1<<uint(32-k)-1))
+	for ; k < l; k++ {
+		// Free the half that does not contain n/l.
+		half := n&^(1<<uint(32-k-1)-1) ^ 1<<uint(32-k-1)
+		p.free[k+1][half] = struct{}{}
+	}
+	return true
+}
+
+// Free returns the number of free addresses in the pool.
+func (p *IPv4Pool) Free() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var free uint64
+	for l, blocks := range p.free {
+		free += uint64(len(blocks)) << uint(32-l)
+	}
+	return free
+}
+
+// Fragmentation returns the fragmentation of the free space of the pool, as
+// a number in [0, 1]: 0 means that all free addresses are in one block (or
+// that there are no free addresses), and values close to 1 mean that the free
+// addresses are scattered in many small blocks. Precisely, it is one minus
+// the ratio of the largest free block to all free addresses, so it tells how
+// much of the free space cannot be handed out as a single prefix. Releasing
+// prefixes in any order brings the fragmentation back to 0 once everything
+// is released, since buddies are always merged.
+func (p *IPv4Pool) Fragmentation() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var free, largest uint64
+	for l, blocks := range p.free {
+		if len(blocks) == 0 {
+			continue
+		}
+		size := uint64(1) << uint(32-l)
+		free += uint64(len(blocks)) * size
+		if largest == 0 {
+			largest = size
+		}
+	}
+	if free == 0 {
+		return 0
+	}
+	return 1 - float64(largest)/float64(free)
+}
+
+// lowestBlock returns the lowest network address in blocks, which must not
+// be empty.
+func lowestBlock(blocks map[uint32]struct{}) uint32 {
+	first := true
+	var lowest uint32
+	for n := range blocks {
+		if first || n < lowest {
+			lowest, first = n, false
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,147 @@
+package nom
+
+import "testing"
+
+func TestIPv4PoolAllocatePrefix(t *testing.T) {
+	p, err := NewIPv4Pool(CIDRToMaskedIPv4(0x0A000000, 24))
+	if err != nil {
+		t.Fatalf("cannot create the pool: %v", err)
+	}
+
+	want := []string{"10.0.0.0/26", "10.0.0.64/27", "10.0.0.128/25"}
+	var prefixes []MaskedIPv4Addr
+	for i, l := range []int{26, 27, 25} {
+		mi, err := p.AllocatePrefix(l)
+		if err != nil || mi.String() != want[i] {
+			t.Fatalf("invalid allocation: actual=%v want=%v err=%v", mi, want[i],
+				err)
+		}
+		prefixes = append(prefixes, mi)
+	}
+	if p.Free() != 32 {
+		t.Errorf("invalid free addresses: actual=%v want=32", p.Free())
+	}
+	if _, err := p.AllocatePrefix(26); err == nil {
+		t.Errorf("allocated a /26 from a pool with a free /27")
+	}
+
+	ip, err := p.Allocate()
+	if err != nil || ip != (IPv4Addr{10, 0, 0, 96}) {
+		t.Errorf("invalid address: actual=%v want=10.0.0.96 err=%v", ip, err)
+	}
+	if f := p.Fragmentation(); f <= 0 {
+		t.Errorf("invalid fragmentation: %v", f)
+	}
+
+	// Host bits are ignored.
+	withHost := prefixes[1]
+	withHost.Addr = withHost.Addr.Next()
+	if err := p.ReleasePrefix(withHost); err != nil {
+		t.Errorf("cannot release %v: %v", withHost, err)
+	}
+	if err := p.ReleasePrefix(prefixes[1]); err == nil {
+		t.Errorf("released %v twice", prefixes[1])
+	}
+	if err := p.Release(ip); err != nil {
+		t.Errorf("cannot release %v: %v", ip, err)
+	}
+	// The /27s are merged back into a /26.
+	mi, err := p.AllocatePrefix(26)
+	if err != nil || mi.String() != "10.0.0.64/26" {
+		t.Errorf("invalid allocation: actual=%v want=10.0.0.64/26 err=%v", mi, err)
+	}
+
+	for _, mi := range append(prefixes[:1], prefixes[2], mi) {
+		if err := p.ReleasePrefix(mi); err != nil {
+			t.Errorf("cannot release %v: %v", mi, err)
+		}
+	}
+	if p.Free() != 256 || p.Fragmentation() != 0 {
+		t.Errorf("pool is not merged: free=%v fragmentation=%v", p.Free(),
+			p.Fragmentation())
+	}
+}
+
+func TestIPv4PoolNested(t *testing.T) {
+	p, _ := NewIPv4Pool(CIDRToMaskedIPv4(0x0A000000, 16))
+	if _, err := p.AllocatePrefix(15); err == nil {
+		t.Errorf("allocated a prefix larger than the pool")
+	}
+	if err := p.ReleasePrefix(CIDRToMaskedIPv4(0x0A000000, 24)); err == nil {
+		t.Errorf("released a prefix that is not allocated")
+	}
+	a, _ := p.AllocatePrefix(24)
+	if err := p.ReleasePrefix(CIDRToMaskedIPv4(0x0A000000, 25)); err == nil {
+		t.Errorf("released a part of %v", a)
+	}
+	all, err := p.AllocatePrefix(16)
+	if err == nil {
+		t.Errorf("allocated %v overlapping %v", all, a)
+	}
+	for i := 0; i < 255; i++ {
+		if _, err := p.AllocatePrefix(24); err != nil {
+			t.Fatalf("cannot allocate /24 #%d: %v", i, err)
+		}
+	}
+	if _, err := p.Allocate(); err == nil {
+		t.Errorf("allocated from a full pool")
+	}
+}
+
+func TestIPv4PoolReserve(t *testing.T) {
+	p, _ := NewIPv4Pool(CIDRToMaskedIPv4(0x0A000000, 29))
+	gw := IPv4Addr{10, 0, 0, 1}
+	for _, ip := range []IPv4Addr{{10, 0, 0, 0}, gw, {10, 0, 0, 7}} {
+		if err := p.Reserve(ip); err != nil {
+			t.Errorf("cannot reserve %v: %v", ip, err)
+		}
+	}
+	if err := p.ReservePrefix(CIDRToMaskedIPv4(0x0A000004, 31)); err != nil {
+		t.Errorf("cannot reserve 10.0.0.4/31: %v", err)
+	}
+	if err := p.Reserve(gw); err == nil {
+		t.Errorf("reserved %v twice", gw)
+	}
+	if err := p.Reserve(IPv4Addr{10, 0, 0, 8}); err == nil {
+		t.Errorf("reserved an address outside the pool")
+	}
+	if err := p.ReservePrefix(CIDRToMaskedIPv4(0x0A000000, 30)); err == nil {
+		t.Errorf("reserved a prefix overlapping reserved addresses")
+	}
+
+	var ips []IPv4Addr
+	for {
+		ip, err := p.Allocate()
+		if err != nil {
+			break
+		}
+		ips = append(ips, ip)
+	}
+	// Smaller free blocks are used first, so 10.0.0.6 comes before the /31.
+	want := []IPv4Addr{{10, 0, 0, 6}, {10, 0, 0, 2}, {10, 0, 0, 3}}
+	if len(ips) != len(want) {
+		t.Fatalf("invalid allocated addresses: actual=%v want=%v", ips, want)
+	}
+	for i := range want {
+		if ips[i] != want[i] {
+			t.Errorf("invalid allocated addresses: actual=%v want=%v", ips, want)
+			break
+		}
+	}
+	if err := p.Reserve(ips[0]); err == nil {
+		t.Errorf("reserved an allocated address")
+	}
+	if err := p.Release(gw); err == nil {
+		t.Errorf("released a reserved address")
+	}
+
+	if err := p.Unreserve(gw); err != nil {
+		t.Errorf("cannot unreserve %v: %v", gw, err)
+	}
+	if err := p.Unreserve(gw); err == nil {
+		t.Errorf("unreserved %v twice", gw)
+	}
+	if ip, err := p.Allocate(); err != nil || ip != gw {
+		t.Errorf("invalid address: actual=%v want=%v err=%v", ip, gw, err)
+	}
+}
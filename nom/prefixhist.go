@@ -0,0 +1,44 @@
+package nom
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// PrefixLenHistogramV4 returns the number of prefixes of each prefix length;
+// ie, the i-th element is the number of /i prefixes. Prefixes are assumed to
+// have contiguous masks.
+func PrefixLenHistogramV4(prefixes []MaskedIPv4Addr) [33]int {
+	var h [33]int
+	for _, p := range prefixes {
+		h[p.PrefixLen()]++
+	}
+	return h
+}
+
+// PrefixLenHistogramV6 is the IPv6 variant of PrefixLenHistogramV4.
+func PrefixLenHistogramV6(prefixes []MaskedIPv6Addr) [129]int {
+	var h [129]int
+	for _, p := range prefixes {
+		h[p.PrefixLen()]++
+	}
+	return h
+}
+
+// FormatPrefixLenHistogram renders a histogram returned by
+// PrefixLenHistogramV4 or PrefixLenHistogramV6 with one line per non-empty
+// prefix length, eg:
+//
+//	/8   1
+//	/24  120
+//
+// Pass the histogram as a slice; eg, FormatPrefixLenHistogram(h[:]).
+func FormatPrefixLenHistogram(h []int) string {
+	var buf bytes.Buffer
+	for l, n := range h {
+		if n != 0 {
+			fmt.Fprintf(&buf, "%-4s %d\n", fmt.Sprintf("/%d", l), n)
+		}
+	}
+	return buf.String()
+}
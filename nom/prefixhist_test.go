@@ -0,0 +1,45 @@
+package nom
+
+import "testing"
+
+func TestPrefixLenHistogramV4(t *testing.T) {
+	var prefixes []MaskedIPv4Addr
+	for _, s := range []string{"10.0.0.0/8", "10.1.0.0/24", "10.2.0.0/24",
+		"0.0.0.0/0", "10.0.0.1/32"} {
+		p, _ := ParseCIDRv4(s)
+		prefixes = append(prefixes, p)
+	}
+	h := PrefixLenHistogramV4(prefixes)
+	sum := 0
+	for _, n := range h {
+		sum += n
+	}
+	if sum != len(prefixes) {
+		t.Errorf("invalid histogram total: actual=%v want=%v", sum, len(prefixes))
+	}
+	want := map[int]int{0: 1, 8: 1, 24: 2, 32: 1}
+	for l, n := range want {
+		if h[l] != n {
+			t.Errorf("invalid count for /%d: actual=%v want=%v", l, h[l], n)
+		}
+	}
+
+	s := FormatPrefixLenHistogram(h[:])
+	wantStr := "/0   1\n/8   1\n/24  2\n/32  1\n"
+	if s != wantStr {
+		t.Errorf("invalid histogram rendering: actual=%q want=%q", s, wantStr)
+	}
+}
+
+func TestPrefixLenHistogramV6(t *testing.T) {
+	var prefixes []MaskedIPv6Addr
+	for _, s := range []string{"2001:db8::/32", "2001:db8:1::/48",
+		"2001:db8:2::/48", "2001:db8::1/128"} {
+		p, _ := ParseCIDRv6(s)
+		prefixes = append(prefixes, p)
+	}
+	h := PrefixLenHistogramV6(prefixes)
+	if h[32] != 1 || h[48] != 2 || h[128] != 1 {
+		t.Errorf("invalid histogram: /32=%v /48=%v /128=%v", h[32], h[48], h[128])
+	}
+}
@@ -0,0 +1,80 @@
+package nom
+
+import "fmt"
+
+// PrefixStore maps IPv4 and IPv6 prefixes to arbitrary values and supports
+// longest prefix matching for addresses of both families, so that dual-stack
+// routes can be managed in one object. Internally, it dispatches each
+// operation to an IPv4Trie or an IPv6Trie by address family. The zero value is
+// an empty store ready to use.
+//
+// PrefixStore is not safe for concurrent use.
+type PrefixStore struct {
+	v4 IPv4Trie
+	v6 IPv6Trie
+}
+
+// NewPrefixStore creates an empty PrefixStore.
+func NewPrefixStore() *PrefixStore {
+	return &PrefixStore{}
+}
+
+// Len returns the number of prefixes of both families stored in s.
+func (s *PrefixStore) Len() int {
+	return s.v4.Len() + s.v6.Len()
+}
+
+// Insert stores value for prefix, replacing the previous value of prefix if
+// any. prefix must be a MaskedIPv4Addr or a MaskedIPv6Addr.
+func (s *PrefixStore) Insert(prefix MaskedAddr, value interface{}) error {
+	switch p := prefix.(type) {
+	case MaskedIPv4Addr:
+		s.v4.Insert(p, value)
+	case MaskedIPv6Addr:
+		s.v6.Insert(p, value)
+	default:
+		return fmt.Errorf("unsupported prefix %v", prefix)
+	}
+	return nil
+}
+
+// Get returns the value stored for exactly prefix.
+func (s *PrefixStore) Get(prefix MaskedAddr) (interface{}, bool) {
+	switch p := prefix.(type) {
+	case MaskedIPv4Addr:
+		return s.v4.Get(p)
+	case MaskedIPv6Addr:
+		return s.v6.Get(p)
+	}
+	return nil, false
+}
+
+// Delete removes prefix from s, and returns whether it was present.
+func (s *PrefixStore) Delete(prefix MaskedAddr) bool {
+	switch p := prefix.(type) {
+	case MaskedIPv4Addr:
+		return s.v4.Delete(p)
+	case MaskedIPv6Addr:
+		return s.v6.Delete(p)
+	}
+	return false
+}
+
+// LongestMatch returns the value of the longest prefix of the same family that
+// matches addr, along with that prefix. It returns false if no prefix matches
+// or if addr is neither an IPv4Addr nor an IPv6Addr.
+func (s *PrefixStore) LongestMatch(addr Addr) (value interface{},
+	prefix MaskedAddr, ok bool) {
+
+	switch a := addr.(type) {
+	case IPv4Addr:
+		if v, p, ok := s.v4.LongestMatch(a); ok {
+			return v, p, true
+		}
+	case IPv6Addr:
+		if v, p, ok := s.v6.LongestMatch(a); ok {
+			return v, p, true
+		}
+	}
+	return nil, nil, false
+}
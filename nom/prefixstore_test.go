@@ -0,0 +1,54 @@
+package nom
+
+import "testing"
+
+func TestPrefixStore(t *testing.T) {
+	s := NewPrefixStore()
+	v4, _ := ParseCIDRv4("10.0.0.0/8")
+	def4 := CIDRToMaskedIPv4(0, 0)
+	v6, _ := ParseCIDRv6("2001:db8::/32")
+	for p, v := range map[MaskedAddr]string{v4: "v4", def4: "def4", v6: "v6"} {
+		if err := s.Insert(p, v); err != nil {
+			t.Fatalf("cannot insert %v: %v", p, err)
+		}
+	}
+	if err := s.Insert(MaskedMACAddr{}, "mac"); err == nil {
+		t.Errorf("inserted a MAC prefix")
+	}
+	if s.Len() != 3 {
+		t.Errorf("invalid store size: actual=%v want=%v", s.Len(), 3)
+	}
+
+	ip6, _ := ParseIPv6("2001:db8::1")
+	other6, _ := ParseIPv6("fe80::1")
+	tests := []struct {
+		addr   Addr
+		value  interface{}
+		prefix MaskedAddr
+		ok     bool
+	}{
+		{IPv4Addr{10, 1, 2, 3}, "v4", v4, true},
+		{IPv4Addr{192, 0, 2, 1}, "def4", def4, true},
+		{ip6, "v6", v6, true},
+		// The IPv4 default route must not match IPv6 addresses.
+		{other6, nil, nil, false},
+		{MACAddr{1, 2, 3, 4, 5, 6}, nil, nil, false},
+	}
+	for _, tc := range tests {
+		v, p, ok := s.LongestMatch(tc.addr)
+		if v != tc.value || p != tc.prefix || ok != tc.ok {
+			t.Errorf("invalid longest match for %v: actual=%v,%v,%v want=%v,%v,%v",
+				tc.addr, v, p, ok, tc.value, tc.prefix, tc.ok)
+		}
+	}
+
+	if !s.Delete(v6) || s.Delete(v6) {
+		t.Errorf("cannot delete %v exactly once", v6)
+	}
+	if _, _, ok := s.LongestMatch(ip6); ok {
+		t.Errorf("unexpected match for %v after delete", ip6)
+	}
+	if v, ok := s.Get(v4); !ok || v != "v4" {
+		t.Errorf("invalid value for %v: actual=%v want=v4", v4, v)
+	}
+}
@@ -0,0 +1,32 @@
+package nom
+
+// RateLimitKeyIPv4 returns a key for rate limiting ip per prefix of the given
+// length; ie, all addresses in the same /prefixLen share the same key. The
+// key is the network part of the prefix, right aligned, and is hence smaller
+// than 1<<prefixLen. Distinct prefixes never collide, but they do when the key
+// is reduced to index a fixed array of token buckets (eg, key % len(buckets)).
+// prefixLen is clamped to [0, 32] as in IPv4Addr.Truncate.
+func RateLimitKeyIPv4(ip IPv4Addr, prefixLen int) uint32 {
+	prefixLen = clampPrefixLen(prefixLen, 32)
+	if prefixLen == 0 {
+		return 0
+	}
+	return ip.Truncate(prefixLen).Uint32() >> uint(32-prefixLen)
+}
+
+// RateLimitKeyIPv6 returns a key for rate limiting ip per prefix of the given
+// length. The key is the hash of the truncated address, so that distinct
+// prefixes may collide and share a rate limit, with a probability of about
+// 1/2^32 per pair before the key is reduced to index an array. prefixLen is
+// clamped to [0, 128].
+func RateLimitKeyIPv6(ip IPv6Addr, prefixLen int) uint32 {
+	return ip.Truncate(prefixLen).Hash()
+}
+
+// RateLimitKeyMAC returns a key for rate limiting mac per prefix of the given
+// length (eg, 24 for per-vendor limits). Like RateLimitKeyIPv6, the key is
+// the hash of the truncated address and may collide. prefixLen is clamped to
+// [0, 48].
+func RateLimitKeyMAC(mac MACAddr, prefixLen int) uint32 {
+	return mac.Truncate(prefixLen).Hash()
+}
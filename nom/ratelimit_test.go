@@ -0,0 +1,53 @@
+package nom
+
+import "testing"
+
+func TestRateLimitKeyIPv4(t *testing.T) {
+	a := IPv4Addr{10, 1, 2, 3}
+	b := IPv4Addr{10, 1, 2, 200}
+	if RateLimitKeyIPv4(a, 24) != RateLimitKeyIPv4(b, 24) {
+		t.Errorf("%v and %v should share the key of their /24", a, b)
+	}
+	if RateLimitKeyIPv4(a, 32) == RateLimitKeyIPv4(b, 32) {
+		t.Errorf("%v and %v should have different /32 keys", a, b)
+	}
+	tests := map[int]uint32{
+		-1: 0, 0: 0, 8: 0x0A, 24: 0x0A0102, 32: 0x0A010203, 40: 0x0A010203,
+	}
+	for l, want := range tests {
+		if actual := RateLimitKeyIPv4(a, l); actual != want {
+			t.Errorf("invalid key for %v/%d: actual=%x want=%x", a, l, actual,
+				want)
+		}
+	}
+}
+
+func TestRateLimitKeyIPv6(t *testing.T) {
+	a, _ := ParseIPv6("2001:db8:1::1")
+	b, _ := ParseIPv6("2001:db8:1::ffff")
+	c, _ := ParseIPv6("2001:db8:2::1")
+	if RateLimitKeyIPv6(a, 48) != RateLimitKeyIPv6(b, 48) {
+		t.Errorf("%v and %v should share the key of their /48", a, b)
+	}
+	if RateLimitKeyIPv6(a, 48) == RateLimitKeyIPv6(c, 48) {
+		t.Errorf("%v and %v should have different /48 keys", a, c)
+	}
+	// The key must be stable across releases, as it may be persisted.
+	if k := RateLimitKeyIPv6(a, 0); k != 0x69691905 {
+		t.Errorf("unstable key for ::/0: actual=%x want=69691905", k)
+	}
+}
+
+func TestRateLimitKeyMAC(t *testing.T) {
+	a := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	b := MACAddr{0x00, 0x11, 0x22, 0x66, 0x77, 0x88}
+	if RateLimitKeyMAC(a, 24) != RateLimitKeyMAC(b, 24) {
+		t.Errorf("%v and %v should share the key of their OUI", a, b)
+	}
+	if RateLimitKeyMAC(a, 48) == RateLimitKeyMAC(b, 48) {
+		t.Errorf("%v and %v should have different keys", a, b)
+	}
+	if k := RateLimitKeyMAC(a, 48); k != a.Hash() {
+		t.Errorf("invalid key for %v: actual=%x want=%x", a, k, a.Hash())
+	}
+}
@@ -0,0 +1,42 @@
+package nom
+
+import "fmt"
+
+// ScopedMAC is a MAC address in a VLAN. L2 tables are keyed on both, since
+// the same MAC address can legitimately appear in different VLANs. See
+// MACTable.PutScoped.
+type ScopedMAC struct {
+	MAC  MACAddr
+	VLAN uint16
+}
+
+// Key returns the 6 bytes of the MAC address followed by the big-endian VLAN
+// ID, which is suitable to store in dictionaries.
+func (s ScopedMAC) Key() string {
+	return string(append(s.MAC[:], byte(s.VLAN>>8), byte(s.VLAN)))
+}
+
+func (s ScopedMAC) String() string {
+	return fmt.Sprintf("%v@vlan%d", s.MAC, s.VLAN)
+}
+
+// ScopedMaskedMAC matches the scoped MAC addresses whose MAC address matches
+// MAC and whose VLAN is VLAN. If AnyVLAN is true, VLAN is ignored and the
+// masked MAC address is matched in every VLAN.
+type ScopedMaskedMAC struct {
+	MAC     MaskedMACAddr
+	VLAN    uint16
+	AnyVLAN bool
+}
+
+// Match returns whether s matches sm.
+func (sm ScopedMaskedMAC) Match(s ScopedMAC) bool {
+	return (sm.AnyVLAN || sm.VLAN == s.VLAN) && sm.MAC.Match(s.MAC)
+}
+
+func (sm ScopedMaskedMAC) String() string {
+	if sm.AnyVLAN {
+		return fmt.Sprintf("%v@vlan*", sm.MAC)
+	}
+	return fmt.Sprintf("%v@vlan%d", sm.MAC, sm.VLAN)
+}
@@ -0,0 +1,58 @@
+package nom
+
+import "testing"
+
+func TestMACTableScoped(t *testing.T) {
+	mac := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	v10 := ScopedMAC{MAC: mac, VLAN: 10}
+	v20 := ScopedMAC{MAC: mac, VLAN: 20}
+	if v10.Key() == v20.Key() {
+		t.Errorf("%v and %v have the same key", v10, v20)
+	}
+
+	tbl := NewMACTable()
+	tbl.PutScoped(v10, 1)
+	tbl.PutScoped(v20, 2)
+	tbl.Put(mac, 0)
+	if tbl.Len() != 3 {
+		t.Errorf("invalid table size: actual=%v want=3", tbl.Len())
+	}
+	if v, ok := tbl.GetScoped(v10); !ok || v != 1 {
+		t.Errorf("invalid value for %v: actual=%v want=1", v10, v)
+	}
+	if v, ok := tbl.GetScoped(v20); !ok || v != 2 {
+		t.Errorf("invalid value for %v: actual=%v want=2", v20, v)
+	}
+	if v, ok := tbl.Get(mac); !ok || v != 0 {
+		t.Errorf("invalid value for %v: actual=%v want=0", mac, v)
+	}
+	tbl.DeleteScoped(v10)
+	if _, ok := tbl.GetScoped(v20); !ok || tbl.Len() != 2 {
+		t.Errorf("deleting %v removed %v", v10, v20)
+	}
+	if f := tbl.ToFlows(func(interface{}) uint32 { return 1 }); len(f) != 1 {
+		t.Errorf("invalid number of flows: actual=%v want=1", len(f))
+	}
+}
+
+func TestScopedMaskedMACMatch(t *testing.T) {
+	mac := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	oui := MaskedMACAddr{Addr: mac, Mask: MACAddr{0xFF, 0xFF, 0xFF}}
+	tests := []struct {
+		sm   ScopedMaskedMAC
+		s    ScopedMAC
+		want bool
+	}{
+		{ScopedMaskedMAC{MAC: oui, VLAN: 10}, ScopedMAC{mac, 10}, true},
+		{ScopedMaskedMAC{MAC: oui, VLAN: 10}, ScopedMAC{mac, 20}, false},
+		{ScopedMaskedMAC{MAC: oui, AnyVLAN: true}, ScopedMAC{mac, 20}, true},
+		{ScopedMaskedMAC{MAC: oui, AnyVLAN: true},
+			ScopedMAC{MACAddr{0x02, 0x11, 0x22}, 20}, false},
+	}
+	for _, tc := range tests {
+		if m := tc.sm.Match(tc.s); m != tc.want {
+			t.Errorf("invalid match of %v against %v: actual=%v want=%v", tc.s,
+				tc.sm, m, tc.want)
+		}
+	}
+}
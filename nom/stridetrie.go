@@ -0,0 +1,138 @@
+package nom
+
+import "fmt"
+
+// strideSlot is a slot of a strideNode. node is the node of the bitTrie that
+// stores the longest prefix of the slot's level that covers the slot, if any.
+type strideSlot struct {
+	node  *trieNode
+	child *strideNode
+}
+
+// strideNode is a level of a multibit trie, indexed by stride bits of the
+// key.
+type strideNode struct {
+	slots []strideSlot
+}
+
+// strideTrie is a multibit trie with a fixed stride over 32-bit keys, which
+// uses controlled prefix expansion: a prefix of length l is stored on level
+// (l-1)/stride (level 0 for l == 0), and is expanded into every slot of that
+// level that it covers. A lookup reads one slot per level, instead of
+// traversing one node per distinguishing bit.
+//
+// The prefixes and values are owned by a bitTrie, and strideTrie is only an
+// index into it.
+type strideTrie struct {
+	stride int
+	root   *strideNode
+}
+
+func newStrideTrie(stride int) *strideTrie {
+	return &strideTrie{stride: stride, root: newStrideNode(stride)}
+}
+
+func newStrideNode(stride int) *strideNode {
+	return &strideNode{slots: make([]strideSlot, 1<<uint(stride))}
+}
+
+// level returns the level on which prefixes of length plen are stored.
+func (t *strideTrie) level(plen int) int {
+	if plen == 0 {
+		return 0
+	}
+	return (plen - 1) / t.stride
+}
+
+// index returns the slot of key on level.
+func (t *strideTrie) index(key uint32, level int) int {
+	return int(key << uint(level*t.stride) >> uint(32-t.stride))
+}
+
+// span returns the first slot and the number of slots covered by key/plen on
+// its level.
+func (t *strideTrie) span(key uint32, plen int) (first, n int) {
+	level := t.level(plen)
+	n = 1 << uint((level+1)*t.stride-plen)
+	return t.index(key, level) &^ (n - 1), n
+}
+
+// node returns the node of the level of plen on the path of key. If create
+// is false, it returns nil if the node does not exist.
+func (t *strideTrie) node(key uint32, plen int, create bool) *strideNode {
+	n := t.root
+	for l := 0; l < t.level(plen); l++ {
+		s := &n.slots[t.index(key, l)]
+		if s.child == nil {
+			if !create {
+				return nil
+			}
+			s.child = newStrideNode(t.stride)
+		}
+		n = s.child
+	}
+	return n
+}
+
+// insert indexes node, which must store key/plen in the bitTrie.
+func (t *strideTrie) insert(key uint32, plen int, node *trieNode) {
+	n := t.node(key, plen, true)
+	first, cnt := t.span(key, plen)
+	for i := first; i < first+cnt; i++ {
+		s := &n.slots[i]
+		if s.node == nil || s.node.plen <= plen {
+			s.node = node
+		}
+	}
+}
+
+// remove updates the index after key/plen is deleted from bt.
+func (t *strideTrie) remove(key uint32, plen int, bt *bitTrie) {
+	n := t.node(key, plen, false)
+	if n == nil {
+		return
+	}
+	level := t.level(plen)
+	keyLen := (level + 1) * t.stride
+	first, cnt := t.span(key, plen)
+	for i := first; i < first+cnt; i++ {
+		// Fall back to the next longest prefix of the same level, if any.
+		k := key&^(^uint32(0)>>uint(level*t.stride)) |
+			uint32(i)<<uint(32-keyLen)
+		var b IPv4Addr
+		b.FromUint(k)
+		best, _ := bt.longestMatch(b[:], keyLen)
+		if best != nil && t.level(best.plen) != level {
+			best = nil
+		}
+		n.slots[i].node = best
+	}
+}
+
+// longestMatch returns the node of the longest prefix that matches key, and
+// the number of levels traversed to find it.
+func (t *strideTrie) longestMatch(key uint32) (best *trieNode, depth int) {
+	for n, l := t.root, 0; n != nil; l++ {
+		s := n.slots[t.index(key, l)]
+		depth++
+		if s.node != nil {
+			best = s.node
+		}
+		n = s.child
+	}
+	return best, depth
+}
+
+// NewIPv4TrieStride creates an empty IPv4Trie that also indexes its prefixes
+// in a multibit trie with the given stride, which must be a divisor of 32 up
+// to 16. Longest prefix matching then reads one slot per stride bits; eg, at
+// most 4 slots with a stride of 8. This trades memory, and slower inserts and
+// deletes, for faster lookups (see BenchmarkIPv4TrieStride). Each level
+// allocates 2^stride slots, so a stride of 16 is only practical for dense
+// tables. Results are identical to those of the default trie.
+func NewIPv4TrieStride(stride int) (*IPv4Trie, error) {
+	if stride < 1 || stride > 16 || 32%stride != 0 {
+		return nil, fmt.Errorf("invalid trie stride %d", stride)
+	}
+	return &IPv4Trie{stride: newStrideTrie(stride)}, nil
+}
@@ -0,0 +1,93 @@
+package nom
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestIPv4TrieStride(t *testing.T) {
+	entries := randIPv4TrieEntries(2000)
+	entries = append(entries,
+		IPv4TrieEntry{Prefix: CIDRToMaskedIPv4(0, 0), Value: "default"},
+		IPv4TrieEntry{Prefix: CIDRToMaskedIPv4(0x0A000000, 7), Value: "odd"})
+
+	for _, stride := range []int{1, 2, 4, 8, 16} {
+		var want IPv4Trie
+		got, err := NewIPv4TrieStride(stride)
+		if err != nil {
+			t.Fatalf("cannot create a trie with stride %d: %v", stride, err)
+		}
+		for _, e := range entries {
+			want.Insert(e.Prefix, e.Value)
+			got.Insert(e.Prefix, e.Value)
+		}
+		// Delete every third prefix to exercise the fallback to shorter ones.
+		for i := 0; i < len(entries); i += 3 {
+			want.Delete(entries[i].Prefix)
+			got.Delete(entries[i].Prefix)
+		}
+		if got.Len() != want.Len() {
+			t.Errorf("invalid trie size with stride %d: actual=%v want=%v", stride,
+				got.Len(), want.Len())
+		}
+
+		r := rand.New(rand.NewSource(3))
+		for i := 0; i < 10000; i++ {
+			var ip IPv4Addr
+			if i%2 == 0 {
+				ip.FromUint(r.Uint32())
+			} else {
+				ip = entries[r.Intn(len(entries))].Prefix.Addr
+			}
+			gv, gp, gok := got.LongestMatch(ip)
+			wv, wp, wok := want.LongestMatch(ip)
+			if gv != wv || gp != wp || gok != wok {
+				t.Errorf("invalid longest match for %v with stride %d: "+
+					"actual=%v(%v) want=%v(%v)", ip, stride, gp, gv, wp, wv)
+			}
+		}
+	}
+}
+
+func TestIPv4TrieStrideDepth(t *testing.T) {
+	trie, _ := NewIPv4TrieStride(8)
+	trie.Insert(CIDRToMaskedIPv4(0x0A010200, 24), 1)
+	_, _, depth, ok := trie.LongestMatchDetailed(IPv4Addr{10, 1, 2, 3})
+	if !ok || depth != 3 {
+		t.Errorf("invalid lookup depth: actual=%v want=3", depth)
+	}
+}
+
+func TestNewIPv4TrieStrideInvalid(t *testing.T) {
+	for _, stride := range []int{0, 3, 32, -1} {
+		if _, err := NewIPv4TrieStride(stride); err == nil {
+			t.Errorf("created a trie with invalid stride %d", stride)
+		}
+	}
+}
+
+func BenchmarkIPv4TrieStride(b *testing.B) {
+	entries := randIPv4TrieEntries(100000)
+	r := rand.New(rand.NewSource(4))
+	ips := make([]IPv4Addr, 1024)
+	for i := range ips {
+		ips[i].FromUint(r.Uint32())
+	}
+
+	// Stride 0 is the default, single-bit trie.
+	for _, stride := range []int{0, 2, 4, 8} {
+		trie := NewIPv4Trie()
+		if stride != 0 {
+			trie, _ = NewIPv4TrieStride(stride)
+		}
+		for _, e := range entries {
+			trie.Insert(e.Prefix, e.Value)
+		}
+		b.Run(fmt.Sprintf("stride=%d", stride), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				trie.LongestMatch(ips[i%len(ips)])
+			}
+		})
+	}
+}
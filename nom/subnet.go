@@ -0,0 +1,289 @@
+package nom
+
+import "fmt"
+
+// Subnets splits the prefix into all of its sub-prefixes of length
+// newPrefixLen. It returns nil if newPrefixLen is shorter than the prefix or
+// longer than 32. Note that the result has 2^(newPrefixLen-PrefixLen())
+// elements; use SubnetsN when the split can be large.
+func (mi MaskedIPv4Addr) Subnets(newPrefixLen int) []MaskedIPv4Addr {
+	subnets, _ := mi.SubnetsN(newPrefixLen, 1<<uint(newPrefixLen-mi.PrefixLen()))
+	return subnets
+}
+
+// SubnetsN is like Subnets but returns at most max sub-prefixes, starting from
+// the lowest one. The returned boolean is true if the result was truncated,
+// ie, if the prefix has more than max sub-prefixes of length newPrefixLen.
+func (mi MaskedIPv4Addr) SubnetsN(newPrefixLen, max int) (
+	subnets []MaskedIPv4Addr, truncated bool) {
+
+	l := mi.PrefixLen()
+	if newPrefixLen < l || newPrefixLen > 32 || max <= 0 {
+		return nil, newPrefixLen >= l && newPrefixLen <= 32
+	}
+
+	n := uint64(1) << uint(newPrefixLen-l)
+	if n > uint64(max) {
+		n, truncated = uint64(max), true
+	}
+	mask := MaskFromPrefixLen4(newPrefixLen)
+	addr := mi.Addr.Mask(mi.Mask).Uint32()
+	step := uint64(1) << uint(32-newPrefixLen)
+	subnets = make([]MaskedIPv4Addr, n)
+	for i := range subnets {
+		subnets[i].Addr.FromUint(uint32(uint64(addr) + uint64(i)*step))
+		subnets[i].Mask = mask
+	}
+	return subnets, truncated
+}
+
+// SubnetsN splits the prefix into its sub-prefixes of length newPrefixLen and
+// returns at most max of them, starting from the lowest one. The returned
+// boolean is true if the result was truncated, ie, if the prefix has more
+// than max sub-prefixes of length newPrefixLen. It returns nil if
+// newPrefixLen is shorter than the prefix or longer than 128.
+//
+// There is no unbounded Subnets for IPv6, since splits of IPv6 prefixes are
+// easily too large to materialize.
+func (mi MaskedIPv6Addr) SubnetsN(newPrefixLen, max int) (
+	subnets []MaskedIPv6Addr, truncated bool) {
+
+	l := mi.PrefixLen()
+	if newPrefixLen < l || newPrefixLen > 128 || max <= 0 {
+		return nil, newPrefixLen >= l && newPrefixLen <= 128
+	}
+
+	n := uint64(max)
+	if bits := uint(newPrefixLen - l); bits < 64 && uint64(1)<<bits <= n {
+		n = uint64(1) << bits
+	} else {
+		truncated = true
+	}
+
+	mask := MaskFromPrefixLen6(newPrefixLen)
+	hi, lo := mi.Addr.Mask(mi.Mask).Uint64s()
+	subnets = make([]MaskedIPv6Addr, n)
+	for i := range subnets {
+		subnets[i].Addr.FromUint64s(hi, lo)
+		subnets[i].Mask = mask
+		hi, lo = addUint128(hi, lo, 128-uint(newPrefixLen))
+	}
+	return subnets, truncated
+}
+
+// addUint128 adds 2^shift to the 128-bit integer hi:lo.
+func addUint128(hi, lo uint64, shift uint) (uint64, uint64) {
+	if shift >= 64 {
+		return hi + 1<<(shift-64), lo
+	}
+	sum := lo + 1<<shift
+	if sum < lo {
+		hi++
+	}
+	return hi, sum
+}
+
+// CommonPrefix returns the longest prefix that contains both mi and thatmi.
+// For example, it returns 10.0.0.0/22 for 10.0.1.0/24 and 10.0.2.0/23.
+func (mi MaskedIPv4Addr) CommonPrefix(thatmi MaskedIPv4Addr) MaskedIPv4Addr {
+	a, b := mi.Network(), thatmi.Network()
+	l := commonPrefixLen(a[:], b[:], mi.PrefixLen(), thatmi.PrefixLen())
+	return MaskedIPv4Addr{
+		Addr: a.Mask(MaskFromPrefixLen4(l)),
+		Mask: MaskFromPrefixLen4(l),
+	}
+}
+
+// IsSiblingOf returns whether mi and thatmi are the two halves of the same
+// parent prefix. For example, 10.0.0.0/25 and 10.0.0.128/25 are siblings.
+func (mi MaskedIPv4Addr) IsSiblingOf(thatmi MaskedIPv4Addr) bool {
+	a, b := mi.Network(), thatmi.Network()
+	return isSibling(a[:], b[:], mi.PrefixLen(), thatmi.PrefixLen())
+}
+
+// CommonPrefix returns the longest prefix that contains both mi and thatmi.
+func (mi MaskedIPv6Addr) CommonPrefix(thatmi MaskedIPv6Addr) MaskedIPv6Addr {
+	a, b := mi.Network(), thatmi.Network()
+	l := commonPrefixLen(a[:], b[:], mi.PrefixLen(), thatmi.PrefixLen())
+	return MaskedIPv6Addr{
+		Addr: a.Mask(MaskFromPrefixLen6(l)),
+		Mask: MaskFromPrefixLen6(l),
+	}
+}
+
+// IsSiblingOf returns whether mi and thatmi are the two halves of the same
+// parent prefix.
+func (mi MaskedIPv6Addr) IsSiblingOf(thatmi MaskedIPv6Addr) bool {
+	a, b := mi.Network(), thatmi.Network()
+	return isSibling(a[:], b[:], mi.PrefixLen(), thatmi.PrefixLen())
+}
+
+// NextBlock returns the prefix of the same length that immediately follows
+// mi; eg, 10.0.1.0/24 for 10.0.0.0/24. It returns false if mi is the last
+// block of its length in the address space. This is useful to carve a space
+// into fixed-size blocks in order.
+func (mi MaskedIPv4Addr) NextBlock() (MaskedIPv4Addr, bool) {
+	l := mi.PrefixLen()
+	next := uint64(mi.Network().Uint32()) + 1<<uint(32-l)
+	if next > 0xFFFFFFFF {
+		return MaskedIPv4Addr{}, false
+	}
+	return CIDRToMaskedIPv4(uint32(next), uint(l)), true
+}
+
+// IndexInSupernet returns which half of its immediate parent the prefix is:
+// 0 for the lower half and 1 for the upper half; eg, 1 for 10.0.0.128/25. It
+// returns false for the /0 prefix, which has no parent, and for
+// non-contiguous masks.
+func (mi MaskedIPv4Addr) IndexInSupernet() (uint64, bool) {
+	l := mi.PrefixLen()
+	if l == 0 {
+		return 0, false
+	}
+	return mi.IndexIn(CIDRToMaskedIPv4(mi.Addr.Uint32(), uint(l-1)))
+}
+
+// IndexIn returns the position of the prefix among the prefixes of the same
+// length in ancestor, counting from 0; eg, 3 for 10.0.3.0/24 in 10.0.0.0/16.
+// This is useful to render address plans as trees. It returns false if
+// ancestor does not contain the prefix or if either has a non-contiguous mask.
+func (mi MaskedIPv4Addr) IndexIn(ancestor MaskedIPv4Addr) (uint64, bool) {
+	if !IsValidNetmask4(mi.Mask) || !IsValidNetmask4(ancestor.Mask) ||
+		!ancestor.Subsumes(mi) {
+		return 0, false
+	}
+	l, al := uint(mi.PrefixLen()), uint(ancestor.PrefixLen())
+	if l == al {
+		return 0, true
+	}
+	n := uint64(mi.Network().Uint32()) >> (32 - l)
+	return n & (1<<(l-al) - 1), true
+}
+
+// NextBlock returns the prefix of the same length that immediately follows
+// mi. It returns false if mi is the last block of its length in the address
+// space.
+func (mi MaskedIPv6Addr) NextBlock() (MaskedIPv6Addr, bool) {
+	l := mi.PrefixLen()
+	hi, lo := mi.Network().Uint64s()
+	nhi, nlo := addUint128(hi, lo, uint(128-l))
+	if l == 0 || nhi < hi {
+		return MaskedIPv6Addr{}, false
+	}
+	next := MaskedIPv6Addr{Mask: mi.Mask}
+	next.Addr.FromUint64s(nhi, nlo)
+	return next, true
+}
+
+// ComplementWithin returns the minimal set of prefixes that cover every
+// address in space except the addresses in mi, sorted by address. This is
+// useful to create "everything except mi" rules. For example, it returns
+// 10.0.0.0/9 and 10.192.0.0/10 for 10.128.0.0/10 within 10.0.0.0/8. It returns
+// an error if mi is not within space, and nil if mi is space itself.
+func (mi MaskedIPv4Addr) ComplementWithin(space MaskedIPv4Addr) (
+	[]MaskedIPv4Addr, error) {
+
+	if !IsValidNetmask4(mi.Mask) || !IsValidNetmask4(space.Mask) ||
+		!space.Subsumes(mi) {
+		return nil, fmt.Errorf("%v is not a prefix within %v", mi, space)
+	}
+
+	var c []MaskedIPv4Addr
+	for l := space.PrefixLen() + 1; l <= mi.PrefixLen(); l++ {
+		sibling := mi.Addr.Truncate(l)
+		flipBit(sibling[:], l-1)
+		c = append(c, MaskedIPv4Addr{Addr: sibling, Mask: MaskFromPrefixLen4(l)})
+	}
+	SortMaskedIPv4s(c)
+	return c, nil
+}
+
+// ComplementWithin returns the minimal set of prefixes that cover every
+// address in space except the addresses in mi, sorted by address. It returns
+// an error if mi is not within space.
+func (mi MaskedIPv6Addr) ComplementWithin(space MaskedIPv6Addr) (
+	[]MaskedIPv6Addr, error) {
+
+	if !IsValidNetmask6(mi.Mask) || !IsValidNetmask6(space.Mask) ||
+		!space.Subsumes(mi) {
+		return nil, fmt.Errorf("%v is not a prefix within %v", mi, space)
+	}
+
+	var c []MaskedIPv6Addr
+	for l := space.PrefixLen() + 1; l <= mi.PrefixLen(); l++ {
+		sibling := mi.Addr.Truncate(l)
+		flipBit(sibling[:], l-1)
+		c = append(c, MaskedIPv6Addr{Addr: sibling, Mask: MaskFromPrefixLen6(l)})
+	}
+	SortMaskedIPv6s(c)
+	return c, nil
+}
+
+// flipBit flips the i-th most significant bit of b.
+func flipBit(b []byte, i int) {
+	b[i/8] ^= 0x80 >> uint(i%8)
+}
+
+// SubnetDiffV4 returns a human-readable description of how a and b relate:
+// whether they are identical, one contains the other, they are siblings, or
+// they are disjoint. Host bits are ignored. For example, it returns
+// "10.0.0.0/16 contains 10.0.1.0/24 (8 more bits)" for 10.0.0.0/16 and
+// 10.0.1.0/24.
+func SubnetDiffV4(a, b MaskedIPv4Addr) string {
+	a, b = a.Canonicalize(), b.Canonicalize()
+	switch {
+	case a == b:
+		return fmt.Sprintf("%v is identical to %v", a, b)
+	case a.Subsumes(b):
+		return fmt.Sprintf("%v contains %v (%d more bits)", a, b,
+			b.PrefixLen()-a.PrefixLen())
+	case b.Subsumes(a):
+		return fmt.Sprintf("%v is contained in %v (%d more bits)", a, b,
+			a.PrefixLen()-b.PrefixLen())
+	case a.IsSiblingOf(b):
+		return fmt.Sprintf("%v and %v are siblings in %v", a, b,
+			a.CommonPrefix(b))
+	case !a.Overlaps(b):
+		return fmt.Sprintf("%v and %v are disjoint within %v", a, b,
+			a.CommonPrefix(b))
+	}
+	// Only prefixes with non-contiguous masks can partially overlap.
+	return fmt.Sprintf("%v and %v partially overlap", a, b)
+}
+
+// SameSubnetIPv4 returns whether a and b are in the same subnet given mask;
+// ie, whether a.Mask(mask) == b.Mask(mask).
+func SameSubnetIPv4(a, b IPv4Addr, mask IPv4Addr) bool {
+	return a.Mask(mask) == b.Mask(mask)
+}
+
+// SameSubnetIPv6 returns whether a and b are in the same subnet given mask.
+func SameSubnetIPv6(a, b IPv6Addr, mask IPv6Addr) bool {
+	return a.Mask(mask) == b.Mask(mask)
+}
+
+// SameSubnetMAC returns whether a and b are equal on the bits of mask. For
+// example, a mask of ff:ff:ff:00:00:00 checks whether a and b share the same
+// OUI.
+func SameSubnetMAC(a, b MACAddr, mask MACAddr) bool {
+	return a.Mask(mask) == b.Mask(mask)
+}
+
+// commonPrefixLen returns the length of the longest common prefix of the
+// prefixes a/al and b/bl.
+func commonPrefixLen(a, b addrBits, al, bl int) int {
+	l := a.CommonPrefixLen(b)
+	if al < l {
+		l = al
+	}
+	if bl < l {
+		l = bl
+	}
+	return l
+}
+
+// isSibling returns whether the prefixes a/al and b/bl only differ in their
+// last bit.
+func isSibling(a, b addrBits, al, bl int) bool {
+	return al == bl && al > 0 && a.CommonPrefixLen(b) == al-1
+}
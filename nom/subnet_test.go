@@ -0,0 +1,341 @@
+package nom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIPv4Subnets(t *testing.T) {
+	p := CIDRToMaskedIPv4(0x0A000000, 24)
+	subnets := p.Subnets(26)
+	if len(subnets) != 4 {
+		t.Fatalf("invalid number of subnets: actual=%v want=%v", len(subnets), 4)
+	}
+	for i, s := range subnets {
+		want := CIDRToMaskedIPv4(0x0A000000+uint32(i)*64, 26)
+		if s != want {
+			t.Errorf("invalid subnet: actual=%v want=%v", s, want)
+		}
+	}
+	if p.Subnets(23) != nil {
+		t.Errorf("split into a shorter prefix")
+	}
+
+	subnets, truncated := p.SubnetsN(32, 10)
+	if len(subnets) != 10 || !truncated {
+		t.Errorf("invalid bounded split: len=%v truncated=%v", len(subnets),
+			truncated)
+	}
+	subnets, truncated = p.SubnetsN(25, 10)
+	if len(subnets) != 2 || truncated {
+		t.Errorf("invalid bounded split: len=%v truncated=%v", len(subnets),
+			truncated)
+	}
+}
+
+func TestIPv6SubnetsN(t *testing.T) {
+	p := MaskedIPv6Addr{
+		Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8},
+		Mask: MaskFromPrefixLen6(32),
+	}
+	subnets, truncated := p.SubnetsN(48, 3)
+	if len(subnets) != 3 || !truncated {
+		t.Fatalf("invalid bounded split: len=%v truncated=%v", len(subnets),
+			truncated)
+	}
+	want := []string{"2001:db8::/48", "2001:db8:1::/48", "2001:db8:2::/48"}
+	for i, s := range subnets {
+		if s.String() != want[i] {
+			t.Errorf("invalid subnet: actual=%v want=%v", s, want[i])
+		}
+	}
+
+	subnets, truncated = p.SubnetsN(33, 3)
+	if len(subnets) != 2 || truncated {
+		t.Errorf("invalid bounded split: len=%v truncated=%v", len(subnets),
+			truncated)
+	}
+	if subnets[1].String() != "2001:db8:8000::/33" {
+		t.Errorf("invalid subnet: actual=%v want=2001:db8:8000::/33", subnets[1])
+	}
+
+	subnets, truncated = p.SubnetsN(128, 2)
+	if len(subnets) != 2 || !truncated || subnets[1].Addr[15] != 1 {
+		t.Errorf("invalid bounded split: %v truncated=%v", subnets, truncated)
+	}
+}
+
+func TestSameSubnetAddrs(t *testing.T) {
+	mask := MaskFromPrefixLen4(24)
+	if !SameSubnetIPv4(IPv4Addr{10, 0, 0, 1}, IPv4Addr{10, 0, 0, 254}, mask) {
+		t.Errorf("10.0.0.1 and 10.0.0.254 should be in the same /24")
+	}
+	if SameSubnetIPv4(IPv4Addr{10, 0, 0, 1}, IPv4Addr{10, 0, 1, 1}, mask) {
+		t.Errorf("10.0.0.1 and 10.0.1.1 should not be in the same /24")
+	}
+
+	a, _ := ParseIPv6("2001:db8::1")
+	b, _ := ParseIPv6("2001:db8::ffff:1")
+	c, _ := ParseIPv6("2001:db9::1")
+	if !SameSubnetIPv6(a, b, MaskFromPrefixLen6(64)) {
+		t.Errorf("%v and %v should be in the same /64", a, b)
+	}
+	if SameSubnetIPv6(a, c, MaskFromPrefixLen6(32)) {
+		t.Errorf("%v and %v should not be in the same /32", a, c)
+	}
+
+	oui := MACAddr{0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00}
+	m1 := MACAddr{0x00, 0x11, 0x22, 0x00, 0x00, 0x01}
+	m2 := MACAddr{0x00, 0x11, 0x22, 0xAA, 0xBB, 0xCC}
+	m3 := MACAddr{0x00, 0x11, 0x23, 0x00, 0x00, 0x01}
+	if !SameSubnetMAC(m1, m2, oui) {
+		t.Errorf("%v and %v should have the same OUI", m1, m2)
+	}
+	if SameSubnetMAC(m1, m3, oui) {
+		t.Errorf("%v and %v should not have the same OUI", m1, m3)
+	}
+}
+
+func TestComplementWithin(t *testing.T) {
+	space := CIDRToMaskedIPv4(0x0A000000, 8)
+	tests := map[MaskedIPv4Addr][]string{
+		CIDRToMaskedIPv4(0x0A800000, 10): {"10.0.0.0/9", "10.192.0.0/10"},
+		CIDRToMaskedIPv4(0x0A000000, 10): {"10.64.0.0/10", "10.128.0.0/9"},
+		CIDRToMaskedIPv4(0x0AFFFFFF, 32): {"10.0.0.0/9", "10.128.0.0/10",
+			"10.192.0.0/11", "10.224.0.0/12", "10.240.0.0/13", "10.248.0.0/14",
+			"10.252.0.0/15", "10.254.0.0/16", "10.255.0.0/17", "10.255.128.0/18",
+			"10.255.192.0/19", "10.255.224.0/20", "10.255.240.0/21",
+			"10.255.248.0/22", "10.255.252.0/23", "10.255.254.0/24",
+			"10.255.255.0/25", "10.255.255.128/26", "10.255.255.192/27",
+			"10.255.255.224/28", "10.255.255.240/29", "10.255.255.248/30",
+			"10.255.255.252/31", "10.255.255.254/32"},
+		space: nil,
+	}
+	for mi, want := range tests {
+		c, err := mi.ComplementWithin(space)
+		if err != nil || len(c) != len(want) {
+			t.Errorf("invalid complement of %v: actual=%v want=%v err=%v", mi, c,
+				want, err)
+			continue
+		}
+		for i := range want {
+			if c[i].String() != want[i] {
+				t.Errorf("invalid complement of %v: actual=%v want=%v", mi, c, want)
+				break
+			}
+		}
+	}
+
+	if _, err := CIDRToMaskedIPv4(0x0B000000, 16).ComplementWithin(
+		space); err == nil {
+		t.Errorf("no error for a prefix outside of %v", space)
+	}
+	if _, err := space.ComplementWithin(
+		CIDRToMaskedIPv4(0x0A000000, 16)); err == nil {
+		t.Errorf("no error for a prefix larger than its space")
+	}
+
+	space6, _ := ParseCIDRv6("2001:db8::/32")
+	mi6, _ := ParseCIDRv6("2001:db8:8000::/34")
+	c6, err := mi6.ComplementWithin(space6)
+	want6 := []string{"2001:db8::/33", "2001:db8:c000::/34"}
+	if err != nil || len(c6) != 2 || c6[0].String() != want6[0] ||
+		c6[1].String() != want6[1] {
+		t.Errorf("invalid complement of %v: actual=%v want=%v err=%v", mi6, c6,
+			want6, err)
+	}
+}
+
+func TestSubnetDiffV4(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"10.0.0.0/24", "10.0.0.7/24", "10.0.0.0/24 is identical to 10.0.0.0/24"},
+		{"10.0.0.0/16", "10.0.1.0/24",
+			"10.0.0.0/16 contains 10.0.1.0/24 (8 more bits)"},
+		{"10.0.1.0/24", "10.0.0.0/16",
+			"10.0.1.0/24 is contained in 10.0.0.0/16 (8 more bits)"},
+		{"10.0.0.0/25", "10.0.0.128/25",
+			"10.0.0.0/25 and 10.0.0.128/25 are siblings in 10.0.0.0/24"},
+		{"10.0.0.0/24", "10.0.2.0/24",
+			"10.0.0.0/24 and 10.0.2.0/24 are disjoint within 10.0.0.0/22"},
+	}
+	for _, tc := range tests {
+		a, _ := ParseCIDRv4(tc.a)
+		b, _ := ParseCIDRv4(tc.b)
+		if d := SubnetDiffV4(a, b); d != tc.want {
+			t.Errorf("invalid diff of %v and %v: actual=%q want=%q", tc.a, tc.b, d,
+				tc.want)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	a := CIDRToMaskedIPv4(0x0A000000, 16)
+	b := CIDRToMaskedIPv4(0x0A000100, 24)
+	c := CIDRToMaskedIPv4(0x0A010000, 16)
+	if !a.Overlaps(b) || !b.Overlaps(a) || a.Overlaps(c) {
+		t.Errorf("invalid overlaps of %v, %v, and %v", a, b, c)
+	}
+
+	a6, _ := ParseCIDRv6("2001:db8::/32")
+	b6, _ := ParseCIDRv6("2001:db8:1::/48")
+	c6, _ := ParseCIDRv6("2001:db9::/32")
+	if !a6.Overlaps(b6) || !b6.Overlaps(a6) || a6.Overlaps(c6) {
+		t.Errorf("invalid overlaps of %v, %v, and %v", a6, b6, c6)
+	}
+
+	// 10.*.1.* and 10.0.*.* share 10.0.1.*, but neither subsumes the other.
+	nc := MaskedIPv4Addr{Addr: IPv4Addr{10, 0, 1, 0},
+		Mask: IPv4Addr{255, 0, 255, 0}}
+	if !nc.Overlaps(a) || !a.Overlaps(nc) || nc.Subsumes(a) || a.Subsumes(nc) {
+		t.Errorf("invalid overlaps of %v and %v", nc, a)
+	}
+	if other := CIDRToMaskedIPv4(0x0B000000, 8); nc.Overlaps(other) {
+		t.Errorf("%v and %v overlap", nc, other)
+	}
+	if d := SubnetDiffV4(nc, a); !strings.HasSuffix(d, "partially overlap") {
+		t.Errorf("invalid diff of %v and %v: %q", nc, a, d)
+	}
+
+	nc6 := MaskedIPv6Addr{Addr: IPv6Addr{0x20, 0x01, 0x0d, 0xb8, 0, 1},
+		Mask: IPv6Addr{0xff, 0xff, 0, 0xff, 0xff, 0xff}}
+	if !nc6.Overlaps(a6) || nc6.Subsumes(a6) || a6.Subsumes(nc6) ||
+		nc6.Overlaps(c6) {
+
+		t.Errorf("invalid overlaps of %v, %v, and %v", nc6, a6, c6)
+	}
+}
+
+func TestWithinSpace(t *testing.T) {
+	space, _ := ParseCIDRv4("10.0.0.0/16")
+	tests := map[string]bool{
+		"10.0.3.0/24": true,
+		"10.0.0.0/16": true,
+		"10.0.0.0/15": false,
+		"10.1.0.0/24": false,
+	}
+	for s, want := range tests {
+		p, _ := ParseCIDRv4(s)
+		if p.WithinSpace(space) != want {
+			t.Errorf("invalid containment of %v in %v: actual=%v want=%v", p, space,
+				!want, want)
+		}
+	}
+
+	space6, _ := ParseCIDRv6("2001:db8::/32")
+	tests6 := map[string]bool{
+		"2001:db8:1::/48": true,
+		"2001:db8::/32":   true,
+		"2001:db8::/31":   false,
+		"2001:db9::/48":   false,
+	}
+	for s, want := range tests6 {
+		p, _ := ParseCIDRv6(s)
+		if p.WithinSpace(space6) != want {
+			t.Errorf("invalid containment of %v in %v: actual=%v want=%v", p,
+				space6, !want, want)
+		}
+	}
+
+	oui := MaskedMACAddr{
+		Addr: MACAddr{0x00, 0x1b, 0x21, 0, 0, 0},
+		Mask: MACAddr{0xff, 0xff, 0xff, 0, 0, 0},
+	}
+	macs := map[MaskedMACAddr]bool{
+		{Addr: MACAddr{0x00, 0x1b, 0x21, 0x01, 0, 0},
+			Mask: MACAddr{0xff, 0xff, 0xff, 0xff, 0, 0}}: true,
+		oui: true,
+		{Addr: MACAddr{0x00, 0x1b, 0x20, 0, 0, 0},
+			Mask: MACAddr{0xff, 0xff, 0xfe, 0, 0, 0}}: false,
+		{Addr: MACAddr{0x00, 0x1b, 0x22, 0x01, 0, 0},
+			Mask: MACAddr{0xff, 0xff, 0xff, 0xff, 0, 0}}: false,
+	}
+	for mm, want := range macs {
+		if mm.WithinSpace(oui) != want {
+			t.Errorf("invalid containment of %v in %v: actual=%v want=%v", mm, oui,
+				!want, want)
+		}
+	}
+}
+
+func TestNextBlock(t *testing.T) {
+	tests := []struct {
+		prefix string
+		next   string
+		ok     bool
+	}{
+		{"10.0.0.0/24", "10.0.1.0/24", true},
+		{"10.0.0.77/24", "10.0.1.0/24", true},
+		{"10.255.255.0/24", "11.0.0.0/24", true},
+		{"255.255.255.0/24", "", false},
+		{"255.255.255.255/32", "", false},
+		{"0.0.0.0/0", "", false},
+	}
+	for _, tc := range tests {
+		p, _ := ParseCIDRv4(tc.prefix)
+		next, ok := p.NextBlock()
+		if ok != tc.ok || (ok && next.String() != tc.next) {
+			t.Errorf("invalid next block of %v: actual=%v,%v want=%v,%v", tc.prefix,
+				next, ok, tc.next, tc.ok)
+		}
+	}
+
+	tests6 := []struct {
+		prefix string
+		next   string
+		ok     bool
+	}{
+		{"2001:db8::/48", "2001:db8:1::/48", true},
+		{"2001:db8:0:0:ffff:ffff:ffff:ffff/128", "2001:db8:0:1::/128", true},
+		{"ffff::/16", "", false},
+		{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ff00/120", "", false},
+		{"::/0", "", false},
+	}
+	for _, tc := range tests6 {
+		p, _ := ParseCIDRv6(tc.prefix)
+		next, ok := p.NextBlock()
+		want, _ := ParseCIDRv6(tc.next)
+		if ok != tc.ok || (ok && next != want) {
+			t.Errorf("invalid next block of %v: actual=%v,%v want=%v,%v", tc.prefix,
+				next, ok, tc.next, tc.ok)
+		}
+	}
+}
+
+func TestIndexIn(t *testing.T) {
+	for i, s := range []string{"10.0.0.0/25", "10.0.0.128/25"} {
+		p, _ := ParseCIDRv4(s)
+		if idx, ok := p.IndexInSupernet(); !ok || idx != uint64(i) {
+			t.Errorf("invalid index of %v in its supernet: actual=%v,%v want=%v",
+				p, idx, ok, i)
+		}
+	}
+	if _, ok := CIDRToMaskedIPv4(0, 0).IndexInSupernet(); ok {
+		t.Errorf("0.0.0.0/0 has an index in its supernet")
+	}
+
+	ancestor, _ := ParseCIDRv4("10.0.0.0/16")
+	tests := []struct {
+		prefix string
+		idx    uint64
+		ok     bool
+	}{
+		{"10.0.0.0/24", 0, true},
+		{"10.0.3.0/24", 3, true},
+		{"10.0.255.0/24", 255, true},
+		{"10.0.3.192/26", 15, true},
+		{"10.0.0.0/16", 0, true},
+		{"10.1.3.0/24", 0, false},
+		{"10.0.0.0/8", 0, false},
+	}
+	for _, tc := range tests {
+		p, _ := ParseCIDRv4(tc.prefix)
+		idx, ok := p.IndexIn(ancestor)
+		if idx != tc.idx || ok != tc.ok {
+			t.Errorf("invalid index of %v in %v: actual=%v,%v want=%v,%v", p,
+				ancestor, idx, ok, tc.idx, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package nom
+
+import "math/big"
+
+// SubnetInfo describes an IPv4 subnet, as shown by subnet calculators.
+type SubnetInfo struct {
+	Network   IPv4Addr
+	Broadcast IPv4Addr
+	First     IPv4Addr // The first usable host address.
+	Last      IPv4Addr // The last usable host address.
+	PrefixLen int
+	Netmask   IPv4Addr
+	Wildcard  IPv4Addr // The inverse of Netmask, as used in Cisco ACLs.
+	NumHosts  uint64   // The number of usable host addresses.
+}
+
+// SubnetInfoV4 returns the information of the IPv4 subnet in cidr, which is
+// parsed by ParseCIDRv4. Host bits in cidr are ignored.
+func SubnetInfoV4(cidr string) (SubnetInfo, error) {
+	mi, err := ParseCIDRv4(cidr)
+	if err != nil {
+		return SubnetInfo{}, err
+	}
+	mi = mi.Canonicalize()
+	var wildcard IPv4Addr
+	wildcard.FromUint(^mi.Mask.Uint32())
+	return SubnetInfo{
+		Network:   mi.Addr,
+		Broadcast: mi.Broadcast(),
+		First:     mi.FirstHost(),
+		Last:      mi.LastHost(),
+		PrefixLen: mi.PrefixLen(),
+		Netmask:   mi.Mask,
+		Wildcard:  wildcard,
+		NumHosts:  mi.NumHosts(),
+	}, nil
+}
+
+// SubnetInfo6 describes an IPv6 subnet. Unlike SubnetInfo, it has no
+// broadcast address since IPv6 has none.
+type SubnetInfo6 struct {
+	Network   IPv6Addr
+	First     IPv6Addr // The first usable host address.
+	Last      IPv6Addr // The last host address.
+	PrefixLen int
+	Netmask   IPv6Addr
+	NumHosts  *big.Int // The number of usable host addresses.
+}
+
+// SubnetInfoV6 returns the information of the IPv6 subnet in cidr, which is
+// parsed by ParseCIDRv6. Host bits in cidr are ignored.
+func SubnetInfoV6(cidr string) (SubnetInfo6, error) {
+	mi, err := ParseCIDRv6(cidr)
+	if err != nil {
+		return SubnetInfo6{}, err
+	}
+	mi = mi.Canonicalize()
+	return SubnetInfo6{
+		Network:   mi.Addr,
+		First:     mi.FirstHost(),
+		Last:      mi.LastHost(),
+		PrefixLen: mi.PrefixLen(),
+		Netmask:   mi.Mask,
+		NumHosts:  mi.NumHosts(),
+	}, nil
+}
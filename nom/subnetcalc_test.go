@@ -0,0 +1,48 @@
+package nom
+
+import "testing"
+
+func TestSubnetInfoV4(t *testing.T) {
+	info, err := SubnetInfoV4("192.168.1.77/24")
+	if err != nil {
+		t.Fatalf("cannot calculate the subnet: %v", err)
+	}
+	want := SubnetInfo{
+		Network:   IPv4Addr{192, 168, 1, 0},
+		Broadcast: IPv4Addr{192, 168, 1, 255},
+		First:     IPv4Addr{192, 168, 1, 1},
+		Last:      IPv4Addr{192, 168, 1, 254},
+		PrefixLen: 24,
+		Netmask:   IPv4Addr{255, 255, 255, 0},
+		Wildcard:  IPv4Addr{0, 0, 0, 255},
+		NumHosts:  254,
+	}
+	if info != want {
+		t.Errorf("invalid subnet info: actual=%+v want=%+v", info, want)
+	}
+
+	info, _ = SubnetInfoV4("10.0.0.0/31")
+	if info.NumHosts != 2 || info.First != info.Network ||
+		info.Last != info.Broadcast {
+		t.Errorf("invalid subnet info for a /31: %+v", info)
+	}
+
+	if _, err := SubnetInfoV4("192.168.1.0"); err == nil {
+		t.Errorf("no error for an invalid subnet")
+	}
+}
+
+func TestSubnetInfoV6(t *testing.T) {
+	info, err := SubnetInfoV6("2001:db8::1/64")
+	if err != nil {
+		t.Fatalf("cannot calculate the subnet: %v", err)
+	}
+	if info.Network.String() != "2001:db8::" ||
+		info.First.String() != "2001:db8::1" ||
+		info.Last.String() != "2001:db8::ffff:ffff:ffff:ffff" ||
+		info.PrefixLen != 64 ||
+		info.Netmask.String() != "ffff:ffff:ffff:ffff::" ||
+		info.NumHosts.String() != "18446744073709551615" {
+		t.Errorf("invalid subnet info: %+v", info)
+	}
+}
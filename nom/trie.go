@@ -0,0 +1,405 @@
+package nom
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// trieNode is a node in a path-compressed binary trie. Each node stores its
+// complete key masked to plen bits, so that single-child chains are collapsed
+// into one node.
+type trieNode struct {
+	key      addrBits
+	plen     int
+	value    interface{}
+	hasValue bool
+	child    [2]*trieNode
+}
+
+// bitTrie is a path-compressed binary trie (ie, a radix tree with a radix of
+// two) keyed on bit strings. It is the common implementation of the address
+// tries.
+type bitTrie struct {
+	root *trieNode
+	size int
+}
+
+func (t *bitTrie) insert(key addrBits, plen int, value interface{}) {
+	key = key.Masked(plen)
+	n := &t.root
+	for {
+		node := *n
+		if node == nil {
+			*n = &trieNode{key: key, plen: plen, value: value, hasValue: true}
+			t.size++
+			return
+		}
+
+		c := node.key.CommonPrefixLen(key)
+		if c > node.plen {
+			c = node.plen
+		}
+		if c > plen {
+			c = plen
+		}
+		switch {
+		case c == node.plen && c == plen:
+			if !node.hasValue {
+				t.size++
+			}
+			node.value = value
+			node.hasValue = true
+			return
+
+		case c == node.plen:
+			n = &node.child[key.BitAt(node.plen)]
+			continue
+
+		case c == plen:
+			parent := &trieNode{key: key, plen: plen, value: value, hasValue: true}
+			parent.child[node.key.BitAt(plen)] = node
+			*n = parent
+
+		default:
+			branch := &trieNode{key: key.Masked(c), plen: c}
+			branch.child[key.BitAt(c)] = &trieNode{
+				key:      key,
+				plen:     plen,
+				value:    value,
+				hasValue: true,
+			}
+			branch.child[node.key.BitAt(c)] = node
+			*n = branch
+		}
+		t.size++
+		return
+	}
+}
+
+// find returns the node that stores exactly key/plen, or nil if there is no
+// such node. The returned path contains the links traversed to reach it,
+// from the root.
+func (t *bitTrie) find(key addrBits, plen int) (path []**trieNode) {
+	key = key.Masked(plen)
+	n := &t.root
+	for *n != nil {
+		node := *n
+		if node.plen > plen || node.key.PrefixCompare(key, node.plen) != 0 {
+			return nil
+		}
+		path = append(path, n)
+		if node.plen == plen {
+			return path
+		}
+		n = &node.child[key.BitAt(node.plen)]
+	}
+	return nil
+}
+
+func (t *bitTrie) get(key addrBits, plen int) (interface{}, bool) {
+	path := t.find(key, plen)
+	if path == nil {
+		return nil, false
+	}
+	node := *path[len(path)-1]
+	return node.value, node.hasValue
+}
+
+func (t *bitTrie) delete(key addrBits, plen int) bool {
+	path := t.find(key, plen)
+	if path == nil || !(*path[len(path)-1]).hasValue {
+		return false
+	}
+
+	node := *path[len(path)-1]
+	node.value = nil
+	node.hasValue = false
+	t.size--
+
+	// Remove the nodes that no longer carry a value nor branch.
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		node := *n
+		if node.hasValue {
+			break
+		}
+		switch {
+		case node.child[0] == nil:
+			*n = node.child[1]
+		case node.child[1] == nil:
+			*n = node.child[0]
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// longestMatch returns the node with the longest prefix that matches key,
+// and the number of nodes traversed to find it.
+func (t *bitTrie) longestMatch(key addrBits, keyLen int) (best *trieNode,
+	depth int) {
+
+	for node := t.root; node != nil; {
+		if node.plen > keyLen || node.key.PrefixCompare(key, node.plen) != 0 {
+			break
+		}
+		depth++
+		if node.hasValue {
+			best = node
+		}
+		if node.plen == keyLen {
+			break
+		}
+		node = node.child[key.BitAt(node.plen)]
+	}
+	return best, depth
+}
+
+// walk calls f for each node with a value in the trie, in the order of their
+// keys and then their prefix lengths.
+func (t *bitTrie) walk(f func(n *trieNode)) {
+	var rec func(n *trieNode)
+	rec = func(n *trieNode) {
+		if n == nil {
+			return
+		}
+		if n.hasValue {
+			f(n)
+		}
+		rec(n.child[0])
+		rec(n.child[1])
+	}
+	rec(t.root)
+}
+
+// buildTrie builds the subtree of the given nodes in one pass. The nodes must
+// be sorted by key and then by prefix length, without duplicates.
+func buildTrie(nodes []*trieNode) *trieNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	first, last := nodes[0], nodes[len(nodes)-1]
+	c := first.key.CommonPrefixLen(last.key)
+	root := &trieNode{key: first.key.Masked(c), plen: c}
+	rest := nodes
+	if first.plen <= c {
+		root, rest = first, nodes[1:]
+	}
+
+	split := sort.Search(len(rest), func(i int) bool {
+		return rest[i].key.BitAt(root.plen) == 1
+	})
+	root.child[0] = buildTrie(rest[:split])
+	root.child[1] = buildTrie(rest[split:])
+	return root
+}
+
+// sortableTrieNode is a trie node along with an integer sort key. The key
+// must order nodes by their prefix and then by their prefix length. Sorting
+// by integer keys is much faster than comparing the keys bit by bit.
+type sortableTrieNode struct {
+	key   uint64
+	index int
+	node  *trieNode
+}
+
+type sortableTrieNodes []sortableTrieNode
+
+func (s sortableTrieNodes) Len() int      { return len(s) }
+func (s sortableTrieNodes) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortableTrieNodes) Less(i, j int) bool {
+	if s[i].key != s[j].key {
+		return s[i].key < s[j].key
+	}
+	return s[i].index < s[j].index
+}
+
+// build replaces the contents of the trie with nodes. When there are
+// duplicate prefixes, the one with the largest index wins.
+func (t *bitTrie) build(nodes []sortableTrieNode) {
+	sort.Sort(sortableTrieNodes(nodes))
+	uniq := make([]*trieNode, 0, len(nodes))
+	for i, n := range nodes {
+		if i != 0 && n.key == nodes[i-1].key {
+			uniq[len(uniq)-1] = n.node
+			continue
+		}
+		uniq = append(uniq, n.node)
+	}
+	t.root = buildTrie(uniq)
+	t.size = len(uniq)
+}
+
+// IPv4TrieEntry is a prefix and its value in an IPv4Trie.
+type IPv4TrieEntry struct {
+	Prefix MaskedIPv4Addr
+	Value  interface{}
+}
+
+// BuildIPv4Trie creates a trie that contains entries. It sorts the entries
+// and builds the trie in one pass with bulk allocations, which is faster than
+// inserting the prefixes one by one (compare BenchmarkBuildIPv4Trie with
+// BenchmarkInsertIPv4Trie). This is useful to load a full routing table on
+// startup. If a prefix is repeated, the last entry wins as it would with
+// Insert.
+func BuildIPv4Trie(entries []IPv4TrieEntry) *IPv4Trie {
+	// Allocate the nodes and their keys in bulk.
+	nodes := make([]sortableTrieNode, len(entries))
+	slab := make([]trieNode, len(entries))
+	keys := make([]byte, IPv4Len*len(entries))
+	for i, e := range entries {
+		l := e.Prefix.PrefixLen()
+		k := e.Prefix.Addr.Uint32() & (^uint32(0) << uint(32-l))
+		key := keys[IPv4Len*i : IPv4Len*(i+1)]
+		binary.BigEndian.PutUint32(key, k)
+		slab[i] = trieNode{key: key, plen: l, value: e.Value, hasValue: true}
+		nodes[i] = sortableTrieNode{
+			key:   uint64(k)<<8 | uint64(l),
+			index: i,
+			node:  &slab[i],
+		}
+	}
+	t := &IPv4Trie{}
+	t.trie.build(nodes)
+	return t
+}
+
+// IPv4Trie maps IPv4 prefixes to arbitrary values and supports longest prefix
+// matching. Prefixes are assumed to have contiguous masks. The zero value is
+// an empty trie ready to use.
+//
+// IPv4Trie is not safe for concurrent use.
+type IPv4Trie struct {
+	trie   bitTrie
+	stride *strideTrie // The multibit index, if any. See NewIPv4TrieStride.
+}
+
+// NewIPv4Trie creates an empty IPv4Trie.
+func NewIPv4Trie() *IPv4Trie {
+	return &IPv4Trie{}
+}
+
+// Len returns the number of prefixes stored in the trie.
+func (t *IPv4Trie) Len() int {
+	return t.trie.size
+}
+
+// Insert stores value for prefix, replacing the previous value of prefix if
+// any. Host bits of the prefix are ignored.
+func (t *IPv4Trie) Insert(prefix MaskedIPv4Addr, value interface{}) {
+	l := prefix.Mask.AsCIDRMask()
+	t.trie.insert(prefix.Addr[:], l, value)
+	if t.stride != nil {
+		path := t.trie.find(prefix.Addr[:], l)
+		t.stride.insert(prefix.Addr.Uint32(), l, *path[len(path)-1])
+	}
+}
+
+// Get returns the value stored for exactly prefix.
+func (t *IPv4Trie) Get(prefix MaskedIPv4Addr) (interface{}, bool) {
+	return t.trie.get(prefix.Addr[:], prefix.Mask.AsCIDRMask())
+}
+
+// Delete removes prefix from the trie, and returns whether it was present.
+func (t *IPv4Trie) Delete(prefix MaskedIPv4Addr) bool {
+	l := prefix.Mask.AsCIDRMask()
+	if !t.trie.delete(prefix.Addr[:], l) {
+		return false
+	}
+	if t.stride != nil {
+		t.stride.remove(prefix.Addr.Uint32(), l, &t.trie)
+	}
+	return true
+}
+
+// LongestMatch returns the value of the longest prefix that matches ip along
+// with the prefix itself.
+func (t *IPv4Trie) LongestMatch(ip IPv4Addr) (value interface{},
+	prefix MaskedIPv4Addr, ok bool) {
+
+	value, prefix, _, ok = t.LongestMatchDetailed(ip)
+	return value, prefix, ok
+}
+
+// LongestMatchDetailed is like LongestMatch but also returns the number of
+// trie nodes traversed for the lookup, which is useful to diagnose
+// pathological tree shapes. Since the trie is path-compressed, depth
+// correlates with, but is usually much smaller than, the length of the
+// matched prefix. For a trie created by NewIPv4TrieStride, depth is the
+// number of levels of the multibit trie read for the lookup.
+func (t *IPv4Trie) LongestMatchDetailed(ip IPv4Addr) (value interface{},
+	prefix MaskedIPv4Addr, depth int, ok bool) {
+
+	var node *trieNode
+	if t.stride != nil {
+		node, depth = t.stride.longestMatch(ip.Uint32())
+	} else {
+		node, depth = t.trie.longestMatch(ip[:], 32)
+	}
+	if node == nil {
+		return nil, MaskedIPv4Addr{}, depth, false
+	}
+	copy(prefix.Addr[:], node.key)
+	prefix.Mask = MaskFromPrefixLen4(node.plen)
+	return node.value, prefix, depth, true
+}
+
+// IPv6Trie maps IPv6 prefixes to arbitrary values and supports longest prefix
+// matching. Prefixes are assumed to have contiguous masks. The zero value is
+// an empty trie ready to use.
+//
+// IPv6Trie is not safe for concurrent use.
+type IPv6Trie struct {
+	trie bitTrie
+}
+
+// NewIPv6Trie creates an empty IPv6Trie.
+func NewIPv6Trie() *IPv6Trie {
+	return &IPv6Trie{}
+}
+
+// Len returns the number of prefixes stored in the trie.
+func (t *IPv6Trie) Len() int {
+	return t.trie.size
+}
+
+// Insert stores value for prefix, replacing the previous value of prefix if
+// any. Host bits of the prefix are ignored.
+func (t *IPv6Trie) Insert(prefix MaskedIPv6Addr, value interface{}) {
+	t.trie.insert(prefix.Addr[:], prefix.Mask.AsCIDRMask(), value)
+}
+
+// Get returns the value stored for exactly prefix.
+func (t *IPv6Trie) Get(prefix MaskedIPv6Addr) (interface{}, bool) {
+	return t.trie.get(prefix.Addr[:], prefix.Mask.AsCIDRMask())
+}
+
+// Delete removes prefix from the trie, and returns whether it was present.
+func (t *IPv6Trie) Delete(prefix MaskedIPv6Addr) bool {
+	return t.trie.delete(prefix.Addr[:], prefix.Mask.AsCIDRMask())
+}
+
+// LongestMatch returns the value of the longest prefix that matches ip along
+// with the prefix itself.
+func (t *IPv6Trie) LongestMatch(ip IPv6Addr) (value interface{},
+	prefix MaskedIPv6Addr, ok bool) {
+
+	value, prefix, _, ok = t.LongestMatchDetailed(ip)
+	return value, prefix, ok
+}
+
+// LongestMatchDetailed is like LongestMatch but also returns the number of
+// trie nodes traversed for the lookup. See IPv4Trie.LongestMatchDetailed.
+func (t *IPv6Trie) LongestMatchDetailed(ip IPv6Addr) (value interface{},
+	prefix MaskedIPv6Addr, depth int, ok bool) {
+
+	node, depth := t.trie.longestMatch(ip[:], 128)
+	if node == nil {
+		return nil, MaskedIPv6Addr{}, depth, false
+	}
+	copy(prefix.Addr[:], node.key)
+	prefix.Mask = MaskFromPrefixLen6(node.plen)
+	return node.value, prefix, depth, true
+}
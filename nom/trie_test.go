@@ -0,0 +1,164 @@
+package nom
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestIPv4TrieLongestMatch(t *testing.T) {
+	var trie IPv4Trie
+	trie.Insert(CIDRToMaskedIPv4(0, 0), "default")
+	trie.Insert(CIDRToMaskedIPv4(0x0A000000, 8), "10/8")
+	trie.Insert(CIDRToMaskedIPv4(0x0A010000, 16), "10.1/16")
+	trie.Insert(CIDRToMaskedIPv4(0x0A010100, 24), "10.1.1/24")
+	trie.Insert(CIDRToMaskedIPv4(0x0A800000, 9), "10.128/9")
+	if trie.Len() != 5 {
+		t.Errorf("invalid trie size: actual=%v want=%v", trie.Len(), 5)
+	}
+
+	lookups := map[IPv4Addr]string{
+		IPv4Addr{10, 1, 1, 1}:   "10.1.1/24",
+		IPv4Addr{10, 1, 2, 1}:   "10.1/16",
+		IPv4Addr{10, 2, 0, 1}:   "10/8",
+		IPv4Addr{10, 200, 0, 1}: "10.128/9",
+		IPv4Addr{11, 0, 0, 1}:   "default",
+	}
+	for ip, want := range lookups {
+		v, p, ok := trie.LongestMatch(ip)
+		if !ok || v != want {
+			t.Errorf("invalid longest match for %v: actual=%v want=%v", ip, v, want)
+		}
+		if !p.Match(ip) {
+			t.Errorf("matched prefix %v does not match %v", p, ip)
+		}
+	}
+
+	_, p, depth, _ := trie.LongestMatchDetailed(IPv4Addr{10, 1, 1, 1})
+	if p != CIDRToMaskedIPv4(0x0A010100, 24) {
+		t.Errorf("invalid matched prefix: actual=%v want=10.1.1.0/24", p)
+	}
+	if depth != 4 {
+		t.Errorf("invalid lookup depth: actual=%v want=%v", depth, 4)
+	}
+}
+
+func TestIPv4TrieDelete(t *testing.T) {
+	var trie IPv4Trie
+	trie.Insert(CIDRToMaskedIPv4(0x0A000000, 8), 8)
+	trie.Insert(CIDRToMaskedIPv4(0x0A010000, 16), 16)
+	trie.Insert(CIDRToMaskedIPv4(0x0A020000, 16), 16)
+
+	if trie.Delete(CIDRToMaskedIPv4(0x0A030000, 16)) {
+		t.Errorf("deleted a prefix that is not in the trie")
+	}
+	if !trie.Delete(CIDRToMaskedIPv4(0x0A010000, 16)) {
+		t.Errorf("cannot delete 10.1.0.0/16")
+	}
+	if v, _, _ := trie.LongestMatch(IPv4Addr{10, 1, 0, 1}); v != 8 {
+		t.Errorf("invalid longest match after delete: actual=%v want=%v", v, 8)
+	}
+	if !trie.Delete(CIDRToMaskedIPv4(0x0A000000, 8)) {
+		t.Errorf("cannot delete 10.0.0.0/8")
+	}
+	if _, _, ok := trie.LongestMatch(IPv4Addr{10, 1, 0, 1}); ok {
+		t.Errorf("unexpected match after deleting 10.0.0.0/8")
+	}
+	if v, ok := trie.Get(CIDRToMaskedIPv4(0x0A020000, 16)); !ok || v != 16 {
+		t.Errorf("invalid value for 10.2.0.0/16: actual=%v want=%v", v, 16)
+	}
+	if trie.Len() != 1 {
+		t.Errorf("invalid trie size: actual=%v want=%v", trie.Len(), 1)
+	}
+}
+
+func randIPv4TrieEntries(n int) []IPv4TrieEntry {
+	r := rand.New(rand.NewSource(1))
+	entries := make([]IPv4TrieEntry, n)
+	for i := range entries {
+		entries[i] = IPv4TrieEntry{
+			Prefix: CIDRToMaskedIPv4(r.Uint32(), uint(8+r.Intn(25))),
+			Value:  i,
+		}
+	}
+	return entries
+}
+
+func TestBuildIPv4Trie(t *testing.T) {
+	entries := randIPv4TrieEntries(1000)
+	entries = append(entries, IPv4TrieEntry{Prefix: entries[0].Prefix,
+		Value: -1})
+	built := BuildIPv4Trie(entries)
+	var inserted IPv4Trie
+	for _, e := range entries {
+		inserted.Insert(e.Prefix, e.Value)
+	}
+	if built.Len() != inserted.Len() {
+		t.Errorf("invalid trie size: actual=%v want=%v", built.Len(),
+			inserted.Len())
+	}
+
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 10000; i++ {
+		var ip IPv4Addr
+		if i%2 == 0 {
+			ip.FromUint(r.Uint32())
+		} else {
+			ip = entries[r.Intn(len(entries))].Prefix.Addr
+		}
+		bv, bp, bok := built.LongestMatch(ip)
+		iv, ip2, iok := inserted.LongestMatch(ip)
+		if bv != iv || bp != ip2 || bok != iok {
+			t.Errorf("invalid longest match for %v: actual=%v(%v) want=%v(%v)", ip,
+				bp, bv, ip2, iv)
+		}
+	}
+}
+
+func BenchmarkBuildIPv4Trie(b *testing.B) {
+	entries := randIPv4TrieEntries(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BuildIPv4Trie(entries)
+	}
+}
+
+func BenchmarkInsertIPv4Trie(b *testing.B) {
+	entries := randIPv4TrieEntries(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var t IPv4Trie
+		for _, e := range entries {
+			t.Insert(e.Prefix, e.Value)
+		}
+	}
+}
+
+func TestIPv6TrieLongestMatch(t *testing.T) {
+	tr := NewIPv6Trie()
+	for _, p := range []string{"::/0", "2001:db8::/32", "2001:db8:1::/48"} {
+		mi, _ := ParseCIDRv6(p)
+		tr.Insert(mi, p)
+	}
+	tests := map[string]string{
+		"2001:db8:1::1": "2001:db8:1::/48",
+		"2001:db8:2::1": "2001:db8::/32",
+		"fe80::1":       "::/0",
+	}
+	for s, want := range tests {
+		ip, _ := ParseIPv6(s)
+		v, p, ok := tr.LongestMatch(ip)
+		if !ok || v != want || p.String() != want {
+			t.Errorf("invalid longest match for %v: actual=%v,%v want=%v", s, v, p,
+				want)
+		}
+	}
+
+	mi, _ := ParseCIDRv6("2001:db8::/32")
+	if !tr.Delete(mi) || tr.Len() != 2 {
+		t.Errorf("cannot delete %v", mi)
+	}
+	ip, _ := ParseIPv6("2001:db8:2::1")
+	if v, _, _ := tr.LongestMatch(ip); v != "::/0" {
+		t.Errorf("invalid longest match after delete: actual=%v want=::/0", v)
+	}
+}
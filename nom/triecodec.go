@@ -0,0 +1,99 @@
+package nom
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// ipv4TrieRecord is a prefix and its value as persisted by
+// IPv4Trie.MarshalBinary. The prefix is in the format of
+// MaskedIPv4Addr.MarshalCompact.
+type ipv4TrieRecord struct {
+	Prefix [5]byte
+	Value  interface{}
+}
+
+// MarshalBinary encodes the prefixes in the trie and their values, so that
+// the trie can be persisted and restored with UnmarshalIPv4Trie. Prefixes
+// are encoded in 5 bytes each and values are encoded with gob. Since values
+// are stored as interface{}, their concrete types must be registered with
+// gob.Register in both the encoding and the decoding processes.
+func (t *IPv4Trie) MarshalBinary() ([]byte, error) {
+	records := make([]ipv4TrieRecord, 0, t.Len())
+	t.trie.walk(func(n *trieNode) {
+		r := ipv4TrieRecord{Value: n.value}
+		copy(r.Prefix[:], n.key)
+		r.Prefix[4] = byte(n.plen)
+		records = append(records, r)
+	})
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(records); err != nil {
+		return nil, fmt.Errorf("cannot encode the trie: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalIPv4Trie restores a trie encoded by IPv4Trie.MarshalBinary.
+// Records are inserted in order, so if a prefix is repeated, the last record
+// wins.
+func UnmarshalIPv4Trie(b []byte) (*IPv4Trie, error) {
+	var records []ipv4TrieRecord
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&records); err != nil {
+		return nil, fmt.Errorf("cannot decode the trie: %v", err)
+	}
+	t := NewIPv4Trie()
+	for _, r := range records {
+		var p MaskedIPv4Addr
+		if err := p.UnmarshalCompact(r.Prefix); err != nil {
+			return nil, err
+		}
+		t.Insert(p, r.Value)
+	}
+	return t, nil
+}
+
+// ipv6TrieRecord is a prefix and its value as persisted by
+// IPv6Trie.MarshalBinary.
+type ipv6TrieRecord struct {
+	Prefix [17]byte
+	Value  interface{}
+}
+
+// MarshalBinary encodes the prefixes in the trie and their values, so that
+// the trie can be persisted and restored with UnmarshalIPv6Trie. See
+// IPv4Trie.MarshalBinary for the requirements on values.
+func (t *IPv6Trie) MarshalBinary() ([]byte, error) {
+	records := make([]ipv6TrieRecord, 0, t.Len())
+	t.trie.walk(func(n *trieNode) {
+		r := ipv6TrieRecord{Value: n.value}
+		copy(r.Prefix[:], n.key)
+		r.Prefix[16] = byte(n.plen)
+		records = append(records, r)
+	})
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(records); err != nil {
+		return nil, fmt.Errorf("cannot encode the trie: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalIPv6Trie restores a trie encoded by IPv6Trie.MarshalBinary. See
+// UnmarshalIPv4Trie.
+func UnmarshalIPv6Trie(b []byte) (*IPv6Trie, error) {
+	var records []ipv6TrieRecord
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&records); err != nil {
+		return nil, fmt.Errorf("cannot decode the trie: %v", err)
+	}
+	t := NewIPv6Trie()
+	for _, r := range records {
+		var p MaskedIPv6Addr
+		if err := p.UnmarshalCompact(r.Prefix); err != nil {
+			return nil, err
+		}
+		t.Insert(p, r.Value)
+	}
+	return t, nil
+}
@@ -0,0 +1,98 @@
+package nom
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func TestIPv4TrieMarshalBinary(t *testing.T) {
+	tr := NewIPv4Trie()
+	for i, p := range []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16",
+		"192.168.1.1/32"} {
+
+		mi, _ := ParseCIDRv4(p)
+		tr.Insert(mi, i)
+	}
+	b, err := tr.MarshalBinary()
+	if err != nil {
+		t.Fatalf("cannot marshal the trie: %v", err)
+	}
+	restored, err := UnmarshalIPv4Trie(b)
+	if err != nil {
+		t.Fatalf("cannot unmarshal the trie: %v", err)
+	}
+	if restored.Len() != tr.Len() {
+		t.Errorf("invalid trie size: actual=%v want=%v", restored.Len(), tr.Len())
+	}
+	for _, ip := range []IPv4Addr{{10, 1, 2, 3}, {10, 2, 0, 0}, {1, 1, 1, 1},
+		{192, 168, 1, 1}} {
+
+		v1, p1, _ := tr.LongestMatch(ip)
+		v2, p2, ok := restored.LongestMatch(ip)
+		if !ok || v1 != v2 || p1 != p2 {
+			t.Errorf("invalid match for %v: actual=%v,%v want=%v,%v", ip, v2, p2,
+				v1, p1)
+		}
+	}
+
+	if _, err := UnmarshalIPv4Trie([]byte("junk")); err == nil {
+		t.Errorf("no error for an invalid encoding")
+	}
+}
+
+func TestIPv6TrieMarshalBinary(t *testing.T) {
+	tr := NewIPv6Trie()
+	for _, p := range []string{"::/0", "2001:db8::/32", "2001:db8:1::/48"} {
+		mi, _ := ParseCIDRv6(p)
+		tr.Insert(mi, p)
+	}
+	b, err := tr.MarshalBinary()
+	if err != nil {
+		t.Fatalf("cannot marshal the trie: %v", err)
+	}
+	restored, err := UnmarshalIPv6Trie(b)
+	if err != nil {
+		t.Fatalf("cannot unmarshal the trie: %v", err)
+	}
+	for _, s := range []string{"2001:db8:1::1", "2001:db8:2::1", "fe80::1"} {
+		ip, _ := ParseIPv6(s)
+		v1, _, _ := tr.LongestMatch(ip)
+		v2, _, ok := restored.LongestMatch(ip)
+		if !ok || v1 != v2 {
+			t.Errorf("invalid match for %v: actual=%v want=%v", ip, v2, v1)
+		}
+	}
+}
+
+func TestUnmarshalTrieDuplicates(t *testing.T) {
+	p4, _ := ParseCIDRv4("10.0.0.0/8")
+	c4, _ := p4.MarshalCompact()
+	d4, _ := CIDRToMaskedIPv4(0, 0).MarshalCompact()
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode([]ipv4TrieRecord{
+		{Prefix: c4, Value: 1}, {Prefix: d4, Value: 2}, {Prefix: c4, Value: 3},
+	})
+	tr, err := UnmarshalIPv4Trie(buf.Bytes())
+	if err != nil {
+		t.Fatalf("cannot unmarshal the trie: %v", err)
+	}
+	if v, ok := tr.Get(p4); tr.Len() != 2 || !ok || v != 3 {
+		t.Errorf("invalid value for %v: actual=%v want=3", p4, v)
+	}
+
+	p6, _ := ParseCIDRv6("2001:db8::/32")
+	c6, _ := p6.MarshalCompact()
+	d6, _ := (MaskedIPv6Addr{}).MarshalCompact()
+	buf.Reset()
+	gob.NewEncoder(&buf).Encode([]ipv6TrieRecord{
+		{Prefix: c6, Value: 1}, {Prefix: d6, Value: 2}, {Prefix: c6, Value: 3},
+	})
+	tr6, err := UnmarshalIPv6Trie(buf.Bytes())
+	if err != nil {
+		t.Fatalf("cannot unmarshal the trie: %v", err)
+	}
+	if v, ok := tr6.Get(p6); tr6.Len() != 2 || !ok || v != 3 {
+		t.Errorf("invalid value for %v: actual=%v want=3", p6, v)
+	}
+}
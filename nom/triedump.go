@@ -0,0 +1,62 @@
+package nom
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// dump writes an indented rendering of the trie to w, one node per line.
+// Each node is written as its prefix (formatted by prefix), its prefix bits,
+// and its value if any. Children are prefixed with the bit that selects them.
+func (t *bitTrie) dump(w io.Writer, prefix func(n *trieNode) string) error {
+	var buf bytes.Buffer
+	var rec func(n *trieNode, depth int, branch string)
+	rec = func(n *trieNode, depth int, branch string) {
+		if n == nil {
+			return
+		}
+		for i := 0; i < depth; i++ {
+			buf.WriteString("  ")
+		}
+		buf.WriteString(branch)
+		fmt.Fprintf(&buf, "%v [", prefix(n))
+		for i := 0; i < n.plen; i++ {
+			buf.WriteByte(byte('0' + n.key.BitAt(i)))
+		}
+		buf.WriteByte(']')
+		if n.hasValue {
+			fmt.Fprintf(&buf, " = %v", n.value)
+		}
+		buf.WriteByte('\n')
+		rec(n.child[0], depth+1, "0: ")
+		rec(n.child[1], depth+1, "1: ")
+	}
+	rec(t.root, 0, "")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// DumpTree writes an indented rendering of the internal radix tree to w, for
+// debugging. Each line shows a node's prefix, its prefix bits, and its value;
+// nodes without a value are branching points created by path compression.
+// The format is meant for humans and may change at any time.
+func (t *IPv4Trie) DumpTree(w io.Writer) error {
+	return t.trie.dump(w, func(n *trieNode) string {
+		var p MaskedIPv4Addr
+		copy(p.Addr[:], n.key)
+		p.Mask = MaskFromPrefixLen4(n.plen)
+		return p.String()
+	})
+}
+
+// DumpTree writes an indented rendering of the internal radix tree to w, for
+// debugging. See IPv4Trie.DumpTree.
+func (t *IPv6Trie) DumpTree(w io.Writer) error {
+	return t.trie.dump(w, func(n *trieNode) string {
+		var p MaskedIPv6Addr
+		copy(p.Addr[:], n.key)
+		p.Mask = MaskFromPrefixLen6(n.plen)
+		return p.String()
+	})
+}
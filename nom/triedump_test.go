@@ -0,0 +1,56 @@
+package nom
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func checkGolden(t *testing.T, name string, actual []byte) {
+	path := "testdata/" + name
+	if *updateGolden {
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("cannot update %v: %v", path, err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read %v: %v", path, err)
+	}
+	if !bytes.Equal(actual, want) {
+		t.Errorf("invalid output for %v:\n%s\nwant:\n%s", path, actual, want)
+	}
+}
+
+func TestIPv4TrieDumpTree(t *testing.T) {
+	tr := NewIPv4Trie()
+	for _, p := range []string{"10.0.0.0/8", "10.128.0.0/9", "11.0.0.0/8",
+		"192.168.1.0/24"} {
+
+		mi, _ := ParseCIDRv4(p)
+		tr.Insert(mi, p)
+	}
+	var buf bytes.Buffer
+	if err := tr.DumpTree(&buf); err != nil {
+		t.Fatalf("cannot dump the trie: %v", err)
+	}
+	checkGolden(t, "ipv4trie.golden", buf.Bytes())
+}
+
+func TestIPv6TrieDumpTree(t *testing.T) {
+	tr := NewIPv6Trie()
+	for _, p := range []string{"2001:db8::/32", "2001:db8:1::/48",
+		"2001:db9::/32"} {
+
+		mi, _ := ParseCIDRv6(p)
+		tr.Insert(mi, p)
+	}
+	var buf bytes.Buffer
+	if err := tr.DumpTree(&buf); err != nil {
+		t.Fatalf("cannot dump the trie: %v", err)
+	}
+	checkGolden(t, "ipv6trie.golden", buf.Bytes())
+}
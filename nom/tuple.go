@@ -0,0 +1,88 @@
+package nom
+
+import "fmt"
+
+// IPv4FiveTuple identifies a transport-layer flow over IPv4.
+type IPv4FiveTuple struct {
+	SrcIP   IPv4Addr
+	DstIP   IPv4Addr
+	SrcPort uint16
+	DstPort uint16
+	Proto   uint8
+}
+
+// Key returns a compact string representation of the tuple suitable to store
+// in dictionaries.
+func (t IPv4FiveTuple) Key() string {
+	b := make([]byte, 0, 2*len(t.SrcIP)+5)
+	b = append(b, t.SrcIP[:]...)
+	b = append(b, t.DstIP[:]...)
+	b = append(b, byte(t.SrcPort>>8), byte(t.SrcPort), byte(t.DstPort>>8),
+		byte(t.DstPort), t.Proto)
+	return string(b)
+}
+
+func (t IPv4FiveTuple) String() string {
+	return fmt.Sprintf("%v:%v->%v:%v/%v", t.SrcIP, t.SrcPort, t.DstIP,
+		t.DstPort, t.Proto)
+}
+
+// SwapTuple returns the tuple of the reverse direction of t, ie, with its
+// source and destination swapped. SwapTuple is its own inverse.
+func SwapTuple(t IPv4FiveTuple) IPv4FiveTuple {
+	return IPv4FiveTuple{
+		SrcIP:   t.DstIP,
+		DstIP:   t.SrcIP,
+		SrcPort: t.DstPort,
+		DstPort: t.SrcPort,
+		Proto:   t.Proto,
+	}
+}
+
+// ReverseFlowKey returns the key of the reverse direction of the flow t. This
+// is used by stateful applications (eg, firewalls and NATs) to correlate the
+// packets of a connection in both directions.
+func ReverseFlowKey(t IPv4FiveTuple) string {
+	return SwapTuple(t).Key()
+}
+
+// IPv6FiveTuple identifies a transport-layer flow over IPv6.
+type IPv6FiveTuple struct {
+	SrcIP   IPv6Addr
+	DstIP   IPv6Addr
+	SrcPort uint16
+	DstPort uint16
+	Proto   uint8
+}
+
+// Key returns a compact string representation of the tuple suitable to store
+// in dictionaries.
+func (t IPv6FiveTuple) Key() string {
+	b := make([]byte, 0, 2*len(t.SrcIP)+5)
+	b = append(b, t.SrcIP[:]...)
+	b = append(b, t.DstIP[:]...)
+	b = append(b, byte(t.SrcPort>>8), byte(t.SrcPort), byte(t.DstPort>>8),
+		byte(t.DstPort), t.Proto)
+	return string(b)
+}
+
+func (t IPv6FiveTuple) String() string {
+	return fmt.Sprintf("[%v]:%v->[%v]:%v/%v", t.SrcIP, t.SrcPort, t.DstIP,
+		t.DstPort, t.Proto)
+}
+
+// SwapTuple6 is like SwapTuple but for IPv6 flows.
+func SwapTuple6(t IPv6FiveTuple) IPv6FiveTuple {
+	return IPv6FiveTuple{
+		SrcIP:   t.DstIP,
+		DstIP:   t.SrcIP,
+		SrcPort: t.DstPort,
+		DstPort: t.SrcPort,
+		Proto:   t.Proto,
+	}
+}
+
+// ReverseFlowKey6 is like ReverseFlowKey but for IPv6 flows.
+func ReverseFlowKey6(t IPv6FiveTuple) string {
+	return SwapTuple6(t).Key()
+}
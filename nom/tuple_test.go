@@ -0,0 +1,39 @@
+package nom
+
+import "testing"
+
+func TestSwapTuple(t *testing.T) {
+	fwd := IPv4FiveTuple{
+		SrcIP:   IPv4Addr{10, 0, 0, 1},
+		DstIP:   IPv4Addr{192, 0, 2, 7},
+		SrcPort: 49152,
+		DstPort: 443,
+		Proto:   6,
+	}
+	rev := SwapTuple(fwd)
+	if rev.SrcIP != fwd.DstIP || rev.SrcPort != fwd.DstPort ||
+		rev.Proto != fwd.Proto {
+		t.Errorf("invalid reverse of %v: %v", fwd, rev)
+	}
+	if SwapTuple(rev) != fwd {
+		t.Errorf("invalid double swap: actual=%v want=%v", SwapTuple(rev), fwd)
+	}
+	if ReverseFlowKey(fwd) != rev.Key() || ReverseFlowKey(rev) != fwd.Key() {
+		t.Errorf("invalid reverse flow key of %v", fwd)
+	}
+	if fwd.Key() == rev.Key() {
+		t.Errorf("%v and %v have the same key", fwd, rev)
+	}
+
+	src, _ := ParseIPv6("2001:db8::1")
+	dst, _ := ParseIPv6("2001:db8::2")
+	fwd6 := IPv6FiveTuple{SrcIP: src, DstIP: dst, SrcPort: 5353, DstPort: 53,
+		Proto: 17}
+	rev6 := SwapTuple6(fwd6)
+	if SwapTuple6(rev6) != fwd6 {
+		t.Errorf("invalid double swap: actual=%v want=%v", SwapTuple6(rev6), fwd6)
+	}
+	if ReverseFlowKey6(fwd6) != rev6.Key() || fwd6.Key() == rev6.Key() {
+		t.Errorf("invalid reverse flow key of %v", fwd6)
+	}
+}
@@ -0,0 +1,49 @@
+package nom
+
+// Well-known control-plane MAC addresses. STP and LLDP use the IEEE 802.1
+// addresses also defined in addr.go, which are reused here.
+var (
+	STPMulticastMAC      MACAddr = IEEE802MulticastPrefix
+	LACPMulticastMAC     MACAddr = [6]byte{0x01, 0x80, 0xC2, 0x00, 0x00, 0x02}
+	LLDPNearestBridgeMAC MACAddr = LLDPMulticastMACs[0]
+	OSPFAllSPFRoutersMAC MACAddr = [6]byte{0x01, 0x00, 0x5E, 0x00, 0x00, 0x05}
+	OSPFAllDRoutersMAC   MACAddr = [6]byte{0x01, 0x00, 0x5E, 0x00, 0x00, 0x06}
+	RIPv2MulticastMAC    MACAddr = [6]byte{0x01, 0x00, 0x5E, 0x00, 0x00, 0x09}
+	VRRPMulticastMAC     MACAddr = [6]byte{0x01, 0x00, 0x5E, 0x00, 0x00, 0x12}
+	PTPMulticastMAC      MACAddr = [6]byte{0x01, 0x1B, 0x19, 0x00, 0x00, 0x00}
+	// VRRPVirtualRouterMAC is the prefix of VRRP virtual router addresses. The
+	// last byte is the virtual router ID.
+	VRRPVirtualRouterMAC MACAddr = [6]byte{0x00, 0x00, 0x5E, 0x00, 0x01, 0x00}
+	IPv6AllNodesMAC      MACAddr = [6]byte{0x33, 0x33, 0x00, 0x00, 0x00, 0x01}
+	IPv6AllRoutersMAC    MACAddr = [6]byte{0x33, 0x33, 0x00, 0x00, 0x00, 0x02}
+)
+
+var wellKnownMACs = map[MACAddr]string{
+	BroadcastMAC:         "Broadcast",
+	CDPMulticastMAC:      "CDP",
+	CiscoSTPMulticastMAC: "Cisco PVST+",
+	STPMulticastMAC:      "STP",
+	LACPMulticastMAC:     "LACP",
+	LLDPNearestBridgeMAC: "LLDP",
+	OSPFAllSPFRoutersMAC: "OSPF Hello (AllSPFRouters)",
+	OSPFAllDRoutersMAC:   "OSPF AllDRouters",
+	RIPv2MulticastMAC:    "RIPv2",
+	VRRPMulticastMAC:     "VRRP advertisement",
+	PTPMulticastMAC:      "PTP",
+	IPv6AllNodesMAC:      "IPv6 all-nodes",
+	IPv6AllRoutersMAC:    "IPv6 all-routers",
+}
+
+// WellKnownMACName returns a human-readable name for well-known control-plane
+// MAC addresses, e.g., "OSPF Hello (AllSPFRouters)" for 01:00:5e:00:00:05.
+// VRRP virtual router addresses (00:00:5e:00:01:XX) are recognized for any
+// virtual router ID.
+func WellKnownMACName(m MACAddr) (string, bool) {
+	if n, ok := wellKnownMACs[m]; ok {
+		return n, true
+	}
+	if m.hasPrefix(VRRPVirtualRouterMAC, 5) {
+		return "VRRP virtual router", true
+	}
+	return "", false
+}
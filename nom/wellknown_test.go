@@ -0,0 +1,33 @@
+package nom
+
+import "testing"
+
+func TestWellKnownMACName(t *testing.T) {
+	macs := map[MACAddr]string{
+		BroadcastMAC:         "Broadcast",
+		CDPMulticastMAC:      "CDP",
+		CiscoSTPMulticastMAC: "Cisco PVST+",
+		STPMulticastMAC:      "STP",
+		LACPMulticastMAC:     "LACP",
+		LLDPNearestBridgeMAC: "LLDP",
+		OSPFAllSPFRoutersMAC: "OSPF Hello (AllSPFRouters)",
+		OSPFAllDRoutersMAC:   "OSPF AllDRouters",
+		RIPv2MulticastMAC:    "RIPv2",
+		VRRPMulticastMAC:     "VRRP advertisement",
+		PTPMulticastMAC:      "PTP",
+		IPv6AllNodesMAC:      "IPv6 all-nodes",
+		IPv6AllRoutersMAC:    "IPv6 all-routers",
+
+		MACAddr{0x00, 0x00, 0x5E, 0x00, 0x01, 0x2A}: "VRRP virtual router",
+	}
+	for m, want := range macs {
+		if n, ok := WellKnownMACName(m); !ok || n != want {
+			t.Errorf("invalid name for %v: actual=%q want=%q", m, n, want)
+		}
+	}
+
+	unicast := MACAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	if n, ok := WellKnownMACName(unicast); ok {
+		t.Errorf("unexpected name for a unicast mac: %q", n)
+	}
+}
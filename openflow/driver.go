@@ -517,8 +517,9 @@ func (d *of12Driver) ofMatch(m nom.Match) (of12.Match, error) {
 				ofm.AddFields(off.OxmField)
 			} else {
 				off := of12.NewOxmEthDstMasked()
-				off.SetMacAddr([6]byte(f.Addr))
-				off.SetMask([6]byte(f.Mask))
+				val, mask := nom.MaskedMACAddr(f).OXM()
+				off.SetMacAddr(val)
+				off.SetMask(mask)
 				ofm.AddFields(off.OxmField)
 			}
 
@@ -529,8 +530,9 @@ func (d *of12Driver) ofMatch(m nom.Match) (of12.Match, error) {
 				ofm.AddFields(off.OxmField)
 			} else {
 				off := of12.NewOxmEthSrcMasked()
-				off.SetMacAddr([6]byte(f.Addr))
-				off.SetMask([6]byte(f.Mask))
+				val, mask := nom.MaskedMACAddr(f).OXM()
+				off.SetMacAddr(val)
+				off.SetMask(mask)
 				ofm.AddFields(off.OxmField)
 			}
 
@@ -551,8 +553,9 @@ func (d *of12Driver) ofMatch(m nom.Match) (of12.Match, error) {
 				ofm.AddFields(off.OxmField)
 			} else {
 				off := of12.NewOxmIpV4SrcMasked()
-				off.SetAddr(f.Addr)
-				off.SetMask(f.Mask)
+				val, mask := nom.MaskedIPv4Addr(f).OXM()
+				off.SetAddr(val)
+				off.SetMask(mask)
 				ofm.AddFields(off.OxmField)
 			}
 
@@ -563,8 +566,9 @@ func (d *of12Driver) ofMatch(m nom.Match) (of12.Match, error) {
 				ofm.AddFields(off.OxmField)
 			} else {
 				off := of12.NewOxmIpV4DstMasked()
-				off.SetAddr(f.Addr)
-				off.SetMask(f.Mask)
+				val, mask := nom.MaskedIPv4Addr(f).OXM()
+				off.SetAddr(val)
+				off.SetMask(mask)
 				ofm.AddFields(off.OxmField)
 			}
 
@@ -575,8 +579,9 @@ func (d *of12Driver) ofMatch(m nom.Match) (of12.Match, error) {
 				ofm.AddFields(off.OxmField)
 			} else {
 				off := of12.NewOxmIpV6SrcMasked()
-				off.SetAddr(f.Addr)
-				off.SetMask(f.Mask)
+				val, mask := nom.MaskedIPv6Addr(f).OXM()
+				off.SetAddr(val)
+				off.SetMask(mask)
 				ofm.AddFields(off.OxmField)
 			}
 
@@ -587,8 +592,9 @@ func (d *of12Driver) ofMatch(m nom.Match) (of12.Match, error) {
 				ofm.AddFields(off.OxmField)
 			} else {
 				off := of12.NewOxmIpV6DstMasked()
-				off.SetAddr(f.Addr)
-				off.SetMask(f.Mask)
+				val, mask := nom.MaskedIPv6Addr(f).OXM()
+				off.SetAddr(val)
+				off.SetMask(mask)
 				ofm.AddFields(off.OxmField)
 			}
 
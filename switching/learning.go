@@ -15,13 +15,12 @@ func (h LearningSwitch) Rcv(msg bh.Msg, ctx bh.RcvContext) error {
 	src := in.Packet.SrcMAC()
 	dst := in.Packet.DstMAC()
 	glog.V(2).Infof("received packet in from %v to %v", src, dst)
-	if dst.IsLLDP() {
+	switch nom.FloodTargets(dst) {
+	case nom.FloodNone:
 		// TODO(soheil): just drop LLDP.
 		glog.Infof("dropped LLDP packet to %v", dst)
 		return nil
-	}
-
-	if dst.IsBroadcast() || dst.IsMulticast() {
+	case nom.FloodAll, nom.FloodMulticastGroup:
 		return h.Hub.Rcv(msg, ctx)
 	}
 